@@ -0,0 +1,195 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+func appendLong(buf []byte, v int64) []byte {
+	var varint [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(varint[:], v)
+	return append(buf, varint[:n]...)
+}
+
+func appendBoolean(buf []byte, v bool) []byte {
+	if v {
+		return append(buf, 1)
+	}
+
+	return append(buf, 0)
+}
+
+func appendFloat(buf []byte, v float32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], math.Float32bits(v))
+	return append(buf, b[:]...)
+}
+
+func appendDouble(buf []byte, v float64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	return append(buf, b[:]...)
+}
+
+func appendBytes(buf []byte, v []byte) []byte {
+	return append(appendLong(buf, int64(len(v))), v...)
+}
+
+//binaryReader reads avro binary primitives, returning errors wrapping io.ErrUnexpectedEOF for truncated data
+type binaryReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *binaryReader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+func (r *binaryReader) long() (int64, error) {
+	v, n := binary.Varint(r.data[r.pos:])
+	if n == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	if n < 0 {
+		return 0, fmt.Errorf("varint overflows a long")
+	}
+
+	r.pos += n
+	return v, nil
+}
+
+func (r *binaryReader) int() (int32, error) {
+	v, err := r.long()
+	if err == nil && (v < math.MinInt32 || v > math.MaxInt32) {
+		err = fmt.Errorf("%v overflows an int", v)
+	}
+
+	return int32(v), err
+}
+
+func (r *binaryReader) boolean() (bool, error) {
+	b, err := r.fixed(1)
+	if err != nil {
+		return false, err
+	}
+
+	switch b[0] {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	}
+
+	return false, fmt.Errorf("invalid boolean %v", b[0])
+}
+
+func (r *binaryReader) float() (float32, error) {
+	b, err := r.fixed(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+}
+
+func (r *binaryReader) double() (float64, error) {
+	b, err := r.fixed(8)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+}
+
+//fixed returns the next size bytes, which share the reader's memory
+func (r *binaryReader) fixed(size int) ([]byte, error) {
+	if size > r.remaining() {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b := r.data[r.pos : r.pos+size]
+	r.pos += size
+	return b, nil
+}
+
+//bytes returns a copy of the next length prefixed bytes
+func (r *binaryReader) bytes() ([]byte, error) {
+	size, err := r.long()
+	if err != nil {
+		return nil, err
+	}
+
+	if size < 0 {
+		return nil, fmt.Errorf("negative length %v", size)
+	}
+
+	if size > int64(r.remaining()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b, err := r.fixed(int(size))
+	return append([]byte{}, b...), err
+}
+
+func (r *binaryReader) string() (string, error) {
+	b, err := r.bytes()
+	return string(b), err
+}
+
+//maxZeroSizeItems bounds the items of a block that can be larger than the data left, which is only possible for items
+//that encode to nothing, like null
+const maxZeroSizeItems = 1 << 16
+
+//blockCount returns the item count of the next array or map block, 0 is the end of the items
+func (r *binaryReader) blockCount() (int64, error) {
+	count, err := r.long()
+	if err != nil {
+		return 0, err
+	}
+
+	//a negative count is followed by the size of the block in bytes, which is only useful for skipping
+	if count < 0 {
+		if count == math.MinInt64 {
+			return 0, fmt.Errorf("invalid block count %v", count)
+		}
+
+		count = -count
+		if _, err = r.long(); err != nil {
+			return 0, err
+		}
+	}
+
+	if count > int64(r.remaining()) && count > maxZeroSizeItems {
+		return 0, fmt.Errorf("block of %v items is larger than the %v bytes left", count, r.remaining())
+	}
+
+	return count, nil
+}
+
+func (r *binaryReader) primitive(t Type) (interface{}, error) {
+	switch t {
+	case Boolean:
+		return r.boolean()
+	case Int:
+		return r.int()
+	case Long:
+		return r.long()
+	case Float:
+		return r.float()
+	case Double:
+		return r.double()
+	case Bytes:
+		return r.bytes()
+	case String:
+		return r.string()
+	}
+
+	return nil, fmt.Errorf("unknown primitive %v", t)
+}
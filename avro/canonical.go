@@ -0,0 +1,136 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+//Canonical returns the Parsing Canonical Form of s as defined by the avro specification.  Two schemas with the same
+//canonical form read and write data the same way, regardless of formatting, docs, defaults and other attributes.
+func Canonical(s Schema) string {
+	var b strings.Builder
+	writeCanonical(&b, s, make(map[string]bool))
+	return b.String()
+}
+
+func writeCanonical(b *strings.Builder, s Schema, defined map[string]bool) {
+	switch s := s.(type) {
+	case *PrimitiveSchema:
+		b.WriteString(strconv.Quote(string(s.Primitive)))
+	case *UnionSchema:
+		b.WriteByte('[')
+		for i, branch := range s.Types {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeCanonical(b, branch, defined)
+		}
+		b.WriteByte(']')
+	case *ArraySchema:
+		b.WriteString(`{"type":"array","items":`)
+		writeCanonical(b, s.Items, defined)
+		b.WriteByte('}')
+	case *MapSchema:
+		b.WriteString(`{"type":"map","values":`)
+		writeCanonical(b, s.Values, defined)
+		b.WriteByte('}')
+	case NamedSchema:
+		name := s.FullName()
+		if defined[name] {
+			b.WriteString(quote(name))
+			return
+		}
+		defined[name] = true
+
+		b.WriteString(`{"name":`)
+		b.WriteString(quote(name))
+		//error records are records in the canonical form
+		typ := s.Type()
+		if typ == Error {
+			typ = Record
+		}
+		b.WriteString(`,"type":`)
+		b.WriteString(quote(string(typ)))
+
+		switch s := s.(type) {
+		case *RecordSchema:
+			b.WriteString(`,"fields":[`)
+			for i, field := range s.Fields {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(`{"name":`)
+				b.WriteString(quote(field.Name))
+				b.WriteString(`,"type":`)
+				writeCanonical(b, field.Type, defined)
+				b.WriteByte('}')
+			}
+			b.WriteByte(']')
+		case *EnumSchema:
+			b.WriteString(`,"symbols":[`)
+			for i, symbol := range s.Symbols {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(quote(symbol))
+			}
+			b.WriteByte(']')
+		case *FixedSchema:
+			b.WriteString(`,"size":`)
+			b.WriteString(strconv.Itoa(s.Size))
+		}
+
+		b.WriteByte('}')
+	}
+}
+
+//quote writes s as a JSON string without the html escaping encoding/json does by default
+func quote(s string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+//crcEmpty is the CRC-64-AVRO initial value and the fingerprint of nothing
+const crcEmpty = 0xc15d213aa4d7a795
+
+var crcTable = func() [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (crcEmpty & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return table
+}()
+
+//Fingerprint64 is the CRC-64-AVRO fingerprint of the canonical form of s, the fingerprint used by avro single object encoding
+func Fingerprint64(s Schema) uint64 {
+	fp := uint64(crcEmpty)
+	for _, b := range []byte(Canonical(s)) {
+		fp = (fp >> 8) ^ crcTable[byte(fp)^b]
+	}
+
+	return fp
+}
+
+//FingerprintMD5 is the MD5 fingerprint of the canonical form of s
+func FingerprintMD5(s Schema) [md5.Size]byte {
+	return md5.Sum([]byte(Canonical(s)))
+}
+
+//FingerprintSHA256 is the SHA-256 fingerprint of the canonical form of s
+func FingerprintSHA256(s Schema) [sha256.Size]byte {
+	return sha256.Sum256([]byte(Canonical(s)))
+}
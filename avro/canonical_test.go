@@ -0,0 +1,57 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonical(t *testing.T) {
+	test := func(schema, expected string) {
+		assert.Equal(t, expected, Canonical(MustParse(schema)), schema)
+	}
+
+	test(`"int"`, `"int"`)
+	test(`{"type": "long", "logicalType": "timestamp-millis"}`, `"long"`)
+	test(`["null", {"type": "string"}]`, `["null","string"]`)
+	test(`{"type": "array", "items": {"type": "map", "values": "bytes"}}`, `{"type":"array","items":{"type":"map","values":"bytes"}}`)
+	test(`{"type": "fixed", "size": 16, "name": "Hash", "namespace": "x.y", "aliases": ["H"]}`, `{"name":"x.y.Hash","type":"fixed","size":16}`)
+	test(`{"symbols": ["A", "B"], "type": "enum", "name": "E", "doc": "letters"}`, `{"name":"E","type":"enum","symbols":["A","B"]}`)
+	test(`{"type": "error", "name": "Oops", "fields": [{"name": "why", "type": "string"}]}`, `{"name":"Oops","type":"record","fields":[{"name":"why","type":"string"}]}`)
+	test(`{
+		"type": "record",
+		"name": "Node",
+		"namespace": "com.mediamath",
+		"doc": "a linked list",
+		"fields": [
+			{"name": "value", "type": "long", "default": 0, "doc": "the value"},
+			{"name": "next", "type": ["null", "Node"], "default": null, "order": "ignore"},
+			{"name": "color", "type": {"type": "enum", "name": "other.Color", "symbols": ["RED"]}},
+			{"name": "again", "type": "other.Color"}
+		]
+	}`, `{"name":"com.mediamath.Node","type":"record","fields":[{"name":"value","type":"long"},{"name":"next","type":["null","com.mediamath.Node"]},{"name":"color","type":{"name":"other.Color","type":"enum","symbols":["RED"]}},{"name":"again","type":"other.Color"}]}`)
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	record := MustParse(`{"type": "record", "name": "Oops", "fields": [{"name": "why", "type": "string"}]}`)
+	errorRecord := MustParse(`{"type": "error", "name": "Oops", "fields": [{"name": "why", "type": "string"}]}`)
+	assert.Equal(t, Fingerprint64(record), Fingerprint64(errorRecord))
+}
+
+func TestFingerprints(t *testing.T) {
+	//values from the avro project's schema-tests.txt, which writes the CRC-64-AVRO fingerprint as a signed java long
+	assert.Equal(t, int64(7195948357588979594), int64(Fingerprint64(MustParse(`"null"`))))
+	assert.Equal(t, int64(8247732601305521295), int64(Fingerprint64(MustParse(`"int"`))))
+	assert.Equal(t, int64(-8142146995180207161), int64(Fingerprint64(MustParse(`{"type": "string"}`))))
+
+	md5 := FingerprintMD5(MustParse(`"int"`))
+	assert.Equal(t, "ef524ea1b91e73173d938ade36c1db32", hex.EncodeToString(md5[:]))
+
+	sha := FingerprintSHA256(MustParse(`"int"`))
+	assert.Equal(t, "3f2b87a9fe7cc9b13835598c3981cd45e3e355309e5090aa0933d7becb6fba45", hex.EncodeToString(sha[:]))
+}
@@ -0,0 +1,386 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func roundTrip(t *testing.T, schema string, v interface{}) interface{} {
+	parsed := MustParse(schema)
+
+	data, err := Marshal(parsed, v)
+	require.NoError(t, err)
+
+	var decoded interface{}
+	require.NoError(t, Unmarshal(parsed, data, &decoded))
+	return decoded
+}
+
+func TestMarshalPrimitives(t *testing.T) {
+	encodings := []struct {
+		schema string
+		value  interface{}
+		data   []byte
+	}{
+		{`"null"`, nil, []byte{}},
+		{`"boolean"`, true, []byte{1}},
+		{`"int"`, 0, []byte{0}},
+		{`"int"`, -1, []byte{1}},
+		{`"int"`, 64, []byte{0x80, 0x01}},
+		{`"long"`, int64(-64), []byte{0x7f}},
+		{`"float"`, float32(1), []byte{0, 0, 0x80, 0x3f}},
+		{`"double"`, 1.0, []byte{0, 0, 0, 0, 0, 0, 0xf0, 0x3f}},
+		{`"bytes"`, []byte{1, 2}, []byte{4, 1, 2}},
+		{`"string"`, "foo", []byte{6, 'f', 'o', 'o'}},
+	}
+
+	for _, encoding := range encodings {
+		data, err := Marshal(MustParse(encoding.schema), encoding.value)
+		require.NoError(t, err, encoding.schema)
+		assert.Equal(t, encoding.data, append([]byte{}, data...), encoding.schema)
+	}
+
+	assert.Equal(t, int32(-5), roundTrip(t, `"int"`, int8(-5)))
+	assert.Equal(t, int64(1<<40), roundTrip(t, `"long"`, uint64(1<<40)))
+	assert.Equal(t, int64(12), roundTrip(t, `"long"`, json.Number("12")))
+	assert.Equal(t, 1.5, roundTrip(t, `"double"`, json.Number("1.5")))
+	assert.Equal(t, "x", roundTrip(t, `"string"`, "x"))
+	assert.Nil(t, roundTrip(t, `"null"`, nil))
+}
+
+func TestMarshalErrors(t *testing.T) {
+	errs := []struct {
+		schema string
+		value  interface{}
+		err    string
+	}{
+		{`"int"`, "1", ".: cannot encode string as int"},
+		{`"int"`, int64(1 << 40), ".: 1099511627776 overflows an int"},
+		{`"string"`, nil, ".: cannot encode nil as string"},
+		{`"double"`, 1, ".: cannot encode int as double"},
+		{`{"type": "enum", "name": "E", "symbols": ["A"]}`, "B", `.: "B" is not a symbol of E`},
+		{`{"type": "fixed", "name": "F", "size": 2}`, []byte{1}, ".: F is 2 bytes, not 1"},
+		{`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`, map[string]interface{}{}, "a: missing field a, which has no default"},
+		{`{"type": "array", "items": "int"}`, []interface{}{1, "x"}, "[]: cannot encode string as int"},
+		{`["null", "int"]`, "x", ".: cannot encode string as int"},
+		{`["int", "string"]`, 1.5, ".: no branch of union [int, string] can encode float64"},
+		{`["int", "string"]`, nil, ".: union [int, string] has no null branch for nil"},
+	}
+
+	for _, e := range errs {
+		_, err := Marshal(MustParse(e.schema), e.value)
+		assert.EqualError(t, err, e.err, e.schema)
+	}
+}
+
+const event = `{"type": "record", "name": "Event", "fields": [
+	{"name": "id", "type": "long"},
+	{"name": "name", "type": ["null", "string"], "default": null},
+	{"name": "tags", "type": {"type": "array", "items": "string"}},
+	{"name": "counts", "type": {"type": "map", "values": "int"}},
+	{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN"]}},
+	{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 2}},
+	{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+	{"name": "next", "type": ["null", "Event"], "default": null}
+]}`
+
+type Event struct {
+	ID     int64 `avro:"id"`
+	Name   *string
+	Tags   []string
+	Counts map[string]int
+	Color  string
+	Hash   [2]byte
+	At     time.Time
+	Next   *Event
+	Ignore string `avro:"-"`
+}
+
+func TestRecordsGeneric(t *testing.T) {
+	value := map[string]interface{}{
+		"id":     int64(1),
+		"tags":   []string{"a", "b"},
+		"counts": map[string]int{"x": 1},
+		"color":  "GREEN",
+		"hash":   []byte{1, 2},
+		"at":     1500,
+		"next": map[string]interface{}{
+			"id":     2,
+			"name":   "inner",
+			"tags":   []interface{}{},
+			"counts": map[string]interface{}{},
+			"color":  "RED",
+			"hash":   [2]byte{3, 4},
+			"at":     0,
+		},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"id":     int64(1),
+		"name":   nil,
+		"tags":   []interface{}{"a", "b"},
+		"counts": map[string]interface{}{"x": int32(1)},
+		"color":  "GREEN",
+		"hash":   []byte{1, 2},
+		"at":     int64(1500),
+		"next": map[string]interface{}{
+			"id":     int64(2),
+			"name":   "inner",
+			"tags":   []interface{}{},
+			"counts": map[string]interface{}{},
+			"color":  "RED",
+			"hash":   []byte{3, 4},
+			"at":     int64(0),
+			"next":   nil,
+		},
+	}, roundTrip(t, event, value))
+}
+
+func TestRecordsStruct(t *testing.T) {
+	schema := MustParse(event)
+	name := "outer"
+	at := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	value := Event{
+		ID:     1,
+		Name:   &name,
+		Tags:   []string{"a"},
+		Counts: map[string]int{"x": 1, "y": 2},
+		Color:  "RED",
+		Hash:   [2]byte{1, 2},
+		At:     at,
+		Next:   &Event{ID: 2, Color: "GREEN", At: at},
+		Ignore: "not written",
+	}
+
+	data, err := Marshal(schema, &value)
+	require.NoError(t, err)
+
+	var decoded Event
+	require.NoError(t, Unmarshal(schema, data, &decoded))
+
+	value.Ignore = ""
+	value.Next.Tags = []string{}
+	value.Next.Counts = map[string]int{}
+	assert.Equal(t, value, decoded)
+
+	var generic interface{}
+	require.NoError(t, Unmarshal(schema, data, &generic))
+	assert.Equal(t, at.UnixNano()/int64(time.Millisecond), generic.(map[string]interface{})["at"])
+}
+
+func TestLogicalTypes(t *testing.T) {
+	date := MustParse(`{"type": "int", "logicalType": "date"}`)
+	data, err := Marshal(date, time.Date(1969, 12, 31, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, data)
+
+	var decoded time.Time
+	require.NoError(t, Unmarshal(date, data, &decoded))
+	assert.Equal(t, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC), decoded)
+
+	micros := MustParse(`{"type": "long", "logicalType": "timestamp-micros"}`)
+	at := time.Date(1960, 1, 2, 3, 4, 5, 6000, time.UTC)
+	data, err = Marshal(micros, at)
+	require.NoError(t, err)
+	require.NoError(t, Unmarshal(micros, data, &decoded))
+	assert.True(t, at.Equal(decoded), "%v != %v", at, decoded)
+
+	timeMillis := MustParse(`{"type": "int", "logicalType": "time-millis"}`)
+	data, err = Marshal(timeMillis, 90*time.Second)
+	require.NoError(t, err)
+
+	var duration time.Duration
+	require.NoError(t, Unmarshal(timeMillis, data, &duration))
+	assert.Equal(t, 90*time.Second, duration)
+
+	_, err = Marshal(MustParse(`"long"`), at)
+	assert.Error(t, err)
+}
+
+func TestDecimals(t *testing.T) {
+	bytesDecimal := MustParse(`{"type": "bytes", "logicalType": "decimal", "precision": 6, "scale": 2}`)
+	fixedDecimal := MustParse(`{"type": "fixed", "name": "Money", "size": 4, "logicalType": "decimal", "precision": 6, "scale": 2}`)
+
+	cases := []struct {
+		value string
+		bytes []byte
+		fixed []byte
+	}{
+		{"0", []byte{2, 0}, []byte{0, 0, 0, 0}},
+		{"1.27", []byte{2, 127}, []byte{0, 0, 0, 127}},
+		{"1.28", []byte{4, 0, 128}, []byte{0, 0, 0, 128}},
+		{"-1.28", []byte{2, 128}, []byte{255, 255, 255, 128}},
+		{"-1.29", []byte{4, 255, 127}, []byte{255, 255, 255, 127}},
+		{"9999.99", []byte{6, 15, 66, 63}, []byte{0, 15, 66, 63}},
+	}
+
+	for _, c := range cases {
+		r, ok := new(big.Rat).SetString(c.value)
+		require.True(t, ok)
+
+		data, err := Marshal(bytesDecimal, r)
+		require.NoError(t, err, c.value)
+		assert.Equal(t, c.bytes, data, c.value)
+
+		decoded := new(big.Rat)
+		require.NoError(t, Unmarshal(bytesDecimal, data, decoded))
+		assert.Equal(t, r.RatString(), decoded.RatString())
+
+		data, err = Marshal(fixedDecimal, *r)
+		require.NoError(t, err, c.value)
+		assert.Equal(t, c.fixed, data, c.value)
+
+		var pointer *big.Rat
+		require.NoError(t, Unmarshal(fixedDecimal, data, &pointer))
+		assert.Equal(t, r.RatString(), pointer.RatString())
+	}
+
+	_, err := Marshal(bytesDecimal, big.NewRat(1, 1000))
+	assert.Error(t, err, "too many decimal places")
+
+	_, err = Marshal(bytesDecimal, big.NewRat(10000, 1))
+	assert.Error(t, err, "too many digits")
+
+	_, err = Marshal(MustParse(`"bytes"`), big.NewRat(1, 1))
+	assert.Error(t, err, "no decimal logical type")
+}
+
+type nameOrID struct {
+	TaggedUnion
+	String *string `avro:"string"`
+	Long   *int64  `avro:"long"`
+	Int    *int32  `avro:"int"`
+}
+
+func TestTaggedUnions(t *testing.T) {
+	schema := MustParse(`["null", "long", "int", "string"]`)
+
+	//int64 would be written as long, the first branch that can encode it, without the tag
+	n := int32(5)
+	data, err := Marshal(schema, nameOrID{Int: &n})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{4, 10}, data)
+
+	var decoded nameOrID
+	require.NoError(t, Unmarshal(schema, data, &decoded))
+	assert.Equal(t, nameOrID{Int: &n}, decoded)
+
+	name := "ann"
+	data, err = Marshal(schema, &nameOrID{String: &name})
+	require.NoError(t, err)
+
+	var pointer *nameOrID
+	require.NoError(t, Unmarshal(schema, data, &pointer))
+	assert.Equal(t, &nameOrID{String: &name}, pointer)
+
+	data, err = Marshal(schema, nameOrID{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0}, data)
+
+	require.NoError(t, Unmarshal(schema, data, &pointer))
+	assert.Nil(t, pointer)
+
+	decoded = nameOrID{Int: &n}
+	require.NoError(t, Unmarshal(schema, data, &decoded))
+	assert.Equal(t, nameOrID{}, decoded)
+
+	_, err = Marshal(schema, nameOrID{String: &name, Int: &n})
+	assert.Error(t, err, "more than one branch")
+
+	_, err = Marshal(MustParse(`["long", "string"]`), nameOrID{})
+	assert.Error(t, err, "no null branch")
+
+	_, err = Marshal(MustParse(`["null", "string"]`), nameOrID{Int: &n})
+	assert.Error(t, err, "not a branch")
+
+	assert.Error(t, Unmarshal(MustParse(`["null", "boolean"]`), []byte{2, 1}, &decoded), "no field for the branch")
+}
+
+func TestUnmarshalResolved(t *testing.T) {
+	writer := MustParse(`{"type": "record", "name": "R", "fields": [
+	{"name": "a", "type": "int"},
+	{"name": "old", "type": {"type": "array", "items": "string"}},
+	{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "BLUE"]}},
+	{"name": "name", "type": "string"},
+	{"name": "maybe", "type": ["null", "int"]}
+]}`)
+	reader := MustParse(`{"type": "record", "name": "R", "fields": [
+	{"name": "a", "type": "double"},
+	{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "OTHER"], "default": "OTHER"}},
+	{"name": "fullName", "type": "bytes", "aliases": ["name"]},
+	{"name": "maybe", "type": ["null", "long"]},
+	{"name": "added", "type": {"type": "map", "values": "bytes"}, "default": {"k": "ÿ"}},
+	{"name": "nested", "type": {"type": "record", "name": "N", "fields": [{"name": "x", "type": "int"}]}, "default": {"x": 7}}
+]}`)
+
+	data, err := Marshal(writer, map[string]interface{}{"a": 3, "old": []string{"x", "y"}, "color": "BLUE", "name": "n", "maybe": 4})
+	require.NoError(t, err)
+
+	var decoded interface{}
+	require.NoError(t, UnmarshalResolved(writer, reader, data, &decoded))
+	assert.Equal(t, map[string]interface{}{
+		"a":        float64(3),
+		"color":    "OTHER",
+		"fullName": []byte("n"),
+		"maybe":    int64(4),
+		"added":    map[string]interface{}{"k": []byte{0xff}},
+		"nested":   map[string]interface{}{"x": int32(7)},
+	}, decoded)
+
+	var typed struct {
+		A        float32
+		FullName []byte
+		Maybe    *int
+		Nested   struct{ X int }
+	}
+	require.NoError(t, UnmarshalResolved(writer, reader, data, &typed))
+	assert.Equal(t, float32(3), typed.A)
+	assert.Equal(t, []byte("n"), typed.FullName)
+	assert.Equal(t, 4, *typed.Maybe)
+	assert.Equal(t, 7, typed.Nested.X)
+
+	//a union writer and a non union reader work for the branches the reader can read
+	nullable := MustParse(`["null", "string"]`)
+	data, err = Marshal(nullable, "s")
+	require.NoError(t, err)
+	require.NoError(t, UnmarshalResolved(nullable, MustParse(`"string"`), data, &decoded))
+	assert.Equal(t, "s", decoded)
+
+	data, err = Marshal(nullable, nil)
+	require.NoError(t, err)
+	assert.EqualError(t, UnmarshalResolved(nullable, MustParse(`"string"`), data, &decoded), ".: reader type string cannot read writer type null")
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	record := MustParse(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "string"}]}`)
+
+	var decoded interface{}
+	err := Unmarshal(record, []byte{6, 'f'}, &decoded)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF), "%v", err)
+	assert.EqualError(t, err, "a: unexpected EOF")
+
+	assert.EqualError(t, Unmarshal(record, []byte{2, 'f', 0}, &decoded), "avro: 1 bytes left after the value")
+	assert.EqualError(t, Unmarshal(MustParse(`["null", "int"]`), []byte{4}, &decoded), ".: union index 2 out of range for [null, int]")
+	assert.EqualError(t, Unmarshal(MustParse(`"boolean"`), []byte{2}, &decoded), ".: invalid boolean 2")
+	assert.EqualError(t, Unmarshal(MustParse(`"int"`), []byte{0xff, 0xff, 0xff, 0xff, 0x7f}, &decoded), ".: -17179869184 overflows an int")
+	assert.EqualError(t, Unmarshal(MustParse(`{"type": "array", "items": "int"}`), []byte{0xfe, 0xff, 0xff, 0xff, 0x0f}, &decoded), ".: block of 2147483647 items is larger than the 0 bytes left")
+
+	var small int8
+	assert.EqualError(t, Unmarshal(MustParse(`"int"`), []byte{0x80, 0x04}, &small), ".: 256 overflows int8")
+
+	var wrong struct{ A int }
+	assert.EqualError(t, Unmarshal(record, []byte{2, 'f'}, &wrong), "a: cannot decode string into int")
+
+	assert.Error(t, Unmarshal(record, []byte{2, 'f'}, decoded))
+}
@@ -0,0 +1,77 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+var ratType = reflect.TypeOf(big.Rat{})
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+//ratValue returns the big.Rat in rv, which is only copied if it cannot be addressed
+func ratValue(rv reflect.Value) *big.Rat {
+	if rv.CanAddr() {
+		return rv.Addr().Interface().(*big.Rat)
+	}
+
+	r := rv.Interface().(big.Rat)
+	return &r
+}
+
+//decimalBytes is the big endian two's complement of the unscaled value of r, sign extended to size if it is not 0
+func decimalBytes(logical *LogicalType, r *big.Rat, size int) ([]byte, error) {
+	if logical == nil || logical.Name != Decimal {
+		return nil, fmt.Errorf("big.Rat needs the decimal logical type")
+	}
+
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(logical.Scale)))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("%v has more than %v decimal places", r.RatString(), logical.Scale)
+	}
+
+	unscaled := scaled.Num()
+	if digits := len(new(big.Int).Abs(unscaled).String()); digits > logical.Precision {
+		return nil, fmt.Errorf("%v has more than %v digits", r.FloatString(logical.Scale), logical.Precision)
+	}
+
+	//the bits of a negative number are the complement of the bits of its magnitude less one
+	magnitude := unscaled
+	if unscaled.Sign() < 0 {
+		magnitude = new(big.Int).Not(unscaled)
+	}
+
+	length := magnitude.BitLen()/8 + 1
+	if size > 0 {
+		if length > size {
+			return nil, fmt.Errorf("%v does not fit in %v bytes", r.FloatString(logical.Scale), size)
+		}
+		length = size
+	}
+
+	b := magnitude.FillBytes(make([]byte, length))
+	if unscaled.Sign() < 0 {
+		for i := range b {
+			b[i] = ^b[i]
+		}
+	}
+
+	return b, nil
+}
+
+//decimalValue is the inverse of decimalBytes
+func decimalValue(logical *LogicalType, b []byte) *big.Rat {
+	unscaled := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+
+	return new(big.Rat).SetFrac(unscaled, pow10(logical.Scale))
+}
@@ -0,0 +1,505 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+//Unmarshal decodes data, the avro binary encoding of a value of schema, into the value v points to.  v may point to an
+//empty interface, which is set to nil, bool, int32, int64, float32, float64, []byte, string (for strings and enums),
+//map[string]interface{} (for records and maps) or []interface{}, with logical types left as their underlying type.
+//Otherwise v points to values of the kinds Marshal takes, with time.Time, time.Duration and big.Rat converted from
+//logical types.  Record fields without a struct field are skipped.
+func Unmarshal(schema Schema, data []byte, v interface{}) error {
+	return UnmarshalResolved(schema, schema, data, v)
+}
+
+//UnmarshalResolved decodes data written with the writer schema into v as the reader schema, following the avro schema
+//resolution rules: fields are matched by name and alias, fields only the writer has are skipped, fields only the reader
+//has get their default, numbers are promoted and unknown enum symbols become the reader default.  See Unmarshal for
+//the values v may point to and CanRead to check the schemas before there is any data.
+func UnmarshalResolved(writer, reader Schema, data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("avro: Unmarshal needs a non nil pointer, not %T", v)
+	}
+
+	d := &decoder{binaryReader: binaryReader{data: data}}
+	if err := d.decode("", writer, reader, rv.Elem()); err != nil {
+		return err
+	}
+
+	if d.remaining() > 0 {
+		return fmt.Errorf("avro: %v bytes left after the value", d.remaining())
+	}
+
+	return nil
+}
+
+var emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+//isGeneric is whether rv is an empty interface, which is set to the generic form of values
+func isGeneric(rv reflect.Value) bool {
+	return rv.Kind() == reflect.Interface && rv.NumMethod() == 0
+}
+
+//discard is somewhere to decode values that are skipped
+func discard() reflect.Value {
+	return reflect.New(emptyInterfaceType).Elem()
+}
+
+type decoder struct {
+	binaryReader
+}
+
+//readError adds the path to an error reading the data, which may wrap io.ErrUnexpectedEOF
+func readError(path string, err error) error {
+	if path == "" {
+		path = "."
+	}
+
+	return fmt.Errorf("%v: %w", path, err)
+}
+
+func (d *decoder) decode(path string, writer, reader Schema, rv reflect.Value) error {
+	if writerUnion, ok := writer.(*UnionSchema); ok {
+		i, err := d.long()
+		if err != nil {
+			return readError(path, err)
+		}
+
+		if i < 0 || i >= int64(len(writerUnion.Types)) {
+			return codecError(path, "union index %v out of range for %v", i, Describe(writerUnion))
+		}
+
+		writer = writerUnion.Types[i]
+	}
+
+	readerUnion, isUnion := reader.(*UnionSchema)
+	if isUnion {
+		branch, ok := readerBranch(readerUnion, writer)
+		if !ok {
+			return codecError(path, "reader union %v has no branch that can read writer type %v", Describe(readerUnion), TypeName(writer))
+		}
+		reader = branch
+	}
+
+	if !matches(reader, writer) {
+		return codecError(path, "reader type %v cannot read writer type %v", TypeName(reader), TypeName(writer))
+	}
+
+	if writer.Type() == Null {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+
+	if isUnion && isTaggedTarget(rv) {
+		field, err := taggedField(path, reader, rv)
+		if err != nil {
+			return err
+		}
+		rv = field
+	}
+
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+
+	switch writer := writer.(type) {
+	case *PrimitiveSchema:
+		v, err := d.primitive(writer.Primitive)
+		if err != nil {
+			return readError(path, err)
+		}
+
+		return store(path, reader, promote(v, reader.Type()), rv)
+	case *RecordSchema:
+		return d.record(path, writer, reader.(*RecordSchema), rv)
+	case *EnumSchema:
+		i, err := d.int()
+		if err != nil {
+			return readError(path, err)
+		}
+
+		if i < 0 || int(i) >= len(writer.Symbols) {
+			return codecError(path, "enum index %v out of range for %v", i, writer.FullName())
+		}
+
+		symbol := writer.Symbols[i]
+		if readerEnum := reader.(*EnumSchema); readerEnum != writer {
+			if _, ok := readerEnum.Symbol(symbol); !ok {
+				if !readerEnum.HasDefault {
+					return codecError(path, "reader enum %v has no symbol %v and no default", readerEnum.FullName(), symbol)
+				}
+				symbol = readerEnum.Default
+			}
+		}
+
+		return store(path, reader, symbol, rv)
+	case *FixedSchema:
+		if size := reader.(*FixedSchema).Size; size != writer.Size {
+			return codecError(path, "reader fixed %v has size %v, writer has size %v", TypeName(reader), size, writer.Size)
+		}
+
+		b, err := d.fixed(writer.Size)
+		if err != nil {
+			return readError(path, err)
+		}
+
+		return store(path, reader, append([]byte{}, b...), rv)
+	case *ArraySchema:
+		return d.array(path, writer, reader.(*ArraySchema), rv)
+	case *MapSchema:
+		return d.mapValues(path, writer, reader.(*MapSchema), rv)
+	}
+
+	return codecError(path, "cannot decode %v", TypeName(writer))
+}
+
+//promote converts a decoded primitive to the type of the reader
+func promote(v interface{}, to Type) interface{} {
+	switch v := v.(type) {
+	case int32:
+		switch to {
+		case Long:
+			return int64(v)
+		case Float:
+			return float32(v)
+		case Double:
+			return float64(v)
+		}
+	case int64:
+		switch to {
+		case Float:
+			return float32(v)
+		case Double:
+			return float64(v)
+		}
+	case float32:
+		if to == Double {
+			return float64(v)
+		}
+	case string:
+		if to == Bytes {
+			return []byte(v)
+		}
+	case []byte:
+		if to == String {
+			return string(v)
+		}
+	}
+
+	return v
+}
+
+func logicalType(s Schema) *LogicalType {
+	switch s := s.(type) {
+	case *PrimitiveSchema:
+		return s.Logical
+	case *FixedSchema:
+		return s.Logical
+	}
+
+	return nil
+}
+
+func logicalName(s Schema) string {
+	if logical := logicalType(s); logical != nil {
+		return logical.Name
+	}
+
+	return ""
+}
+
+//store sets rv to a decoded value of a primitive, enum or fixed
+func store(path string, s Schema, v interface{}, rv reflect.Value) error {
+	if isGeneric(rv) {
+		rv.Set(reflect.ValueOf(v))
+		return nil
+	}
+
+	var n int64
+	isInteger := false
+	switch i := v.(type) {
+	case int32:
+		n, isInteger = int64(i), true
+	case int64:
+		n, isInteger = i, true
+	}
+
+	switch {
+	case rv.Type() == timeType && isInteger:
+		t, err := toTime(logicalName(s), n)
+		if err != nil {
+			return codecError(path, "%v", err)
+		}
+		rv.Set(reflect.ValueOf(t))
+		return nil
+	case rv.Type() == durationType && isInteger && logicalName(s) == TimeMillis:
+		rv.SetInt(n * int64(time.Millisecond))
+		return nil
+	case rv.Type() == durationType && isInteger && logicalName(s) == TimeMicros:
+		rv.SetInt(n * int64(time.Microsecond))
+		return nil
+	case rv.Type() == ratType && logicalName(s) == Decimal:
+		if b, ok := v.([]byte); ok {
+			rv.Set(reflect.ValueOf(decimalValue(logicalType(s), b)).Elem())
+			return nil
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		if b, ok := v.(bool); ok {
+			rv.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isInteger {
+			if rv.OverflowInt(n) {
+				return codecError(path, "%v overflows %v", n, rv.Type())
+			}
+			rv.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if isInteger {
+			if n < 0 || rv.OverflowUint(uint64(n)) {
+				return codecError(path, "%v overflows %v", n, rv.Type())
+			}
+			rv.SetUint(uint64(n))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch f := v.(type) {
+		case float32:
+			rv.SetFloat(float64(f))
+			return nil
+		case float64:
+			rv.SetFloat(f)
+			return nil
+		}
+	case reflect.String:
+		if str, ok := v.(string); ok {
+			rv.SetString(str)
+			return nil
+		}
+	case reflect.Slice:
+		if b, ok := v.([]byte); ok && rv.Type().Elem().Kind() == reflect.Uint8 {
+			rv.SetBytes(b)
+			return nil
+		}
+	case reflect.Array:
+		if b, ok := v.([]byte); ok && rv.Type().Elem().Kind() == reflect.Uint8 && rv.Len() == len(b) {
+			reflect.Copy(rv, reflect.ValueOf(b))
+			return nil
+		}
+	}
+
+	return codecError(path, "cannot decode %v into %v", TypeName(s), rv.Type())
+}
+
+func toTime(logical string, n int64) (time.Time, error) {
+	switch logical {
+	case Date:
+		return time.Unix(n*86400, 0).UTC(), nil
+	case TimestampMillis, LocalTimestampMillis:
+		return time.Unix(n/1000, (n%1000)*int64(time.Millisecond)).UTC(), nil
+	case TimestampMicros, LocalTimestampMicros:
+		return time.Unix(n/1000000, (n%1000000)*int64(time.Microsecond)).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("time.Time needs a date or timestamp logical type")
+}
+
+//fieldSetter returns how to decode the fields of a record into rv, which is replaced by a map if it is generic
+func fieldSetter(path string, s *RecordSchema, rv reflect.Value) (func(name string, decode func(reflect.Value) error) error, error) {
+	if isGeneric(rv) {
+		m := reflect.ValueOf(make(map[string]interface{}, len(s.Fields)))
+		rv.Set(m)
+		rv = m
+	}
+
+	switch {
+	case rv.Kind() == reflect.Struct:
+		return func(name string, decode func(reflect.Value) error) error {
+			field, ok := structField(rv, name)
+			if !ok {
+				return decode(discard())
+			}
+			return decode(field)
+		}, nil
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+
+		return func(name string, decode func(reflect.Value) error) error {
+			value := reflect.New(rv.Type().Elem()).Elem()
+			if err := decode(value); err != nil {
+				return err
+			}
+
+			rv.SetMapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()), value)
+			return nil
+		}, nil
+	}
+
+	return nil, codecError(path, "cannot decode %v into %v", s.FullName(), rv.Type())
+}
+
+func (d *decoder) record(path string, writer, reader *RecordSchema, rv reflect.Value) error {
+	set, err := fieldSetter(path, reader, rv)
+	if err != nil {
+		return err
+	}
+
+	readerFields := make(map[string]*Field, len(reader.Fields))
+	for _, field := range reader.Fields {
+		if writerField, ok := WriterField(field, writer); ok {
+			readerFields[writerField.Name] = field
+		}
+	}
+
+	for _, writerField := range writer.Fields {
+		fieldPath := JoinPath(path, writerField.Name)
+
+		readerField, ok := readerFields[writerField.Name]
+		if !ok {
+			if err := d.decode(fieldPath, writerField.Type, writerField.Type, discard()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := set(readerField.Name, func(value reflect.Value) error {
+			return d.decode(JoinPath(path, readerField.Name), writerField.Type, readerField.Type, value)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, field := range reader.Fields {
+		if _, ok := WriterField(field, writer); ok {
+			continue
+		}
+
+		fieldPath := JoinPath(path, field.Name)
+		if !field.HasDefault {
+			return codecError(fieldPath, "reader field %v has no default and is missing from writer %v", field.Name, writer.FullName())
+		}
+
+		//the default is decoded from its encoding so it is converted exactly like written data
+		encoded, err := Marshal(field.Type, defaultValue(field.Type, field.Default))
+		if err != nil {
+			return codecError(fieldPath, "default: %v", err)
+		}
+
+		err = set(field.Name, func(value reflect.Value) error {
+			return (&decoder{binaryReader: binaryReader{data: encoded}}).decode(fieldPath, field.Type, field.Type, value)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//blocks calls item for each item of an array or map
+func (d *decoder) blocks(path string, item func() error) error {
+	for {
+		count, err := d.blockCount()
+		if err != nil {
+			return readError(path, err)
+		}
+
+		if count == 0 {
+			return nil
+		}
+
+		for i := int64(0); i < count; i++ {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (d *decoder) array(path string, writer, reader *ArraySchema, rv reflect.Value) error {
+	itemPath := JoinPath(path, "[]")
+
+	switch {
+	case isGeneric(rv):
+		items := []interface{}{}
+		err := d.blocks(path, func() error {
+			var item interface{}
+			err := d.decode(itemPath, writer.Items, reader.Items, reflect.ValueOf(&item).Elem())
+			items = append(items, item)
+			return err
+		})
+		rv.Set(reflect.ValueOf(items))
+		return err
+	case rv.Kind() == reflect.Slice:
+		rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+		return d.blocks(path, func() error {
+			item := reflect.New(rv.Type().Elem()).Elem()
+			err := d.decode(itemPath, writer.Items, reader.Items, item)
+			rv.Set(reflect.Append(rv, item))
+			return err
+		})
+	case rv.Kind() == reflect.Array:
+		i := 0
+		err := d.blocks(path, func() error {
+			if i >= rv.Len() {
+				return codecError(path, "more than %v items for %v", rv.Len(), rv.Type())
+			}
+			i++
+			return d.decode(itemPath, writer.Items, reader.Items, rv.Index(i-1))
+		})
+		return err
+	}
+
+	return codecError(path, "cannot decode %v into %v", Describe(reader), rv.Type())
+}
+
+func (d *decoder) mapValues(path string, writer, reader *MapSchema, rv reflect.Value) error {
+	if isGeneric(rv) {
+		m := reflect.ValueOf(map[string]interface{}{})
+		rv.Set(m)
+		rv = m
+	}
+
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return codecError(path, "cannot decode %v into %v", Describe(reader), rv.Type())
+	}
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rv.Type()))
+	}
+
+	valuePath := JoinPath(path, "{}")
+	return d.blocks(path, func() error {
+		key, err := d.string()
+		if err != nil {
+			return readError(path, err)
+		}
+
+		value := reflect.New(rv.Type().Elem()).Elem()
+		if err := d.decode(valuePath, writer.Values, reader.Values, value); err != nil {
+			return err
+		}
+
+		rv.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), value)
+		return nil
+	})
+}
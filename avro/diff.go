@@ -0,0 +1,308 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+//ChangeKind is the kind of difference between two schemas
+type ChangeKind string
+
+const (
+	//FieldAdded is a field in the new record that is not in the old one
+	FieldAdded = ChangeKind("field_added")
+	//FieldRemoved is a field in the old record that is not in the new one
+	FieldRemoved = ChangeKind("field_removed")
+	//FieldRenamed is a field in the new record with an alias naming a field of the old one
+	FieldRenamed = ChangeKind("field_renamed")
+	//TypeChanged is a schema whose type is different
+	TypeChanged = ChangeKind("type_changed")
+	//NameChanged is a record, enum or fixed whose full name is different
+	NameChanged = ChangeKind("name_changed")
+	//DefaultChanged is a field or enum whose default was added, removed or changed
+	DefaultChanged = ChangeKind("default_changed")
+	//SymbolAdded is an enum symbol in the new schema that is not in the old one
+	SymbolAdded = ChangeKind("symbol_added")
+	//SymbolRemoved is an enum symbol in the old schema that is not in the new one
+	SymbolRemoved = ChangeKind("symbol_removed")
+	//DocChanged is a record, enum, fixed or field whose doc is different
+	DocChanged = ChangeKind("doc_changed")
+)
+
+//Change is a difference between two schemas.  Path is the location in the new schema, in the form used by
+//Incompatibility.  Old and New describe the value before and after, they are empty if there was none.
+type Change struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "."
+	}
+
+	switch c.Kind {
+	case FieldAdded:
+		return fmt.Sprintf("%v: field added with type %v", path, c.New)
+	case FieldRemoved:
+		return fmt.Sprintf("%v: field removed, was %v", path, c.Old)
+	case FieldRenamed:
+		return fmt.Sprintf("%v: field renamed from %v", path, c.Old)
+	case SymbolAdded:
+		return fmt.Sprintf("%v: symbol %v added", path, c.New)
+	case SymbolRemoved:
+		return fmt.Sprintf("%v: symbol %v removed", path, c.Old)
+	case DocChanged:
+		return fmt.Sprintf("%v: doc changed from %q to %q", path, c.Old, c.New)
+	}
+
+	return fmt.Sprintf("%v: %v from %v to %v", path, strings.Replace(string(c.Kind), "_", " ", -1), orNone(c.Old), orNone(c.New))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+
+	return s
+}
+
+//Diff returns the semantic differences between two schemas.  Formatting, attribute order and other differences that
+//do not change the parsed schema are ignored.
+func Diff(before, after Schema) []Change {
+	d := &differ{compared: make(map[[2]string]bool)}
+	d.diff("", before, after)
+	return d.changes
+}
+
+type differ struct {
+	compared map[[2]string]bool
+	changes  []Change
+}
+
+func (d *differ) add(path string, kind ChangeKind, before, after string) {
+	d.changes = append(d.changes, Change{Path: path, Kind: kind, Old: before, New: after})
+}
+
+func (d *differ) diff(path string, before, after Schema) {
+	if before.Type() != after.Type() && !(isRecord(before) && isRecord(after)) {
+		d.add(path, TypeChanged, Describe(before), Describe(after))
+		return
+	}
+
+	switch before := before.(type) {
+	case *PrimitiveSchema:
+		if Describe(before) != Describe(after) {
+			d.add(path, TypeChanged, Describe(before), Describe(after))
+		}
+	case *ArraySchema:
+		d.diff(JoinPath(path, "[]"), before.Items, after.(*ArraySchema).Items)
+	case *MapSchema:
+		d.diff(JoinPath(path, "{}"), before.Values, after.(*MapSchema).Values)
+	case *UnionSchema:
+		d.union(path, before, after.(*UnionSchema))
+	case *FixedSchema:
+		after := after.(*FixedSchema)
+		d.named(path, before, after, before.Doc, after.Doc)
+		if Describe(before) != Describe(after) {
+			d.add(path, TypeChanged, Describe(before), Describe(after))
+		}
+	case *EnumSchema:
+		after := after.(*EnumSchema)
+		if !d.named(path, before, after, before.Doc, after.Doc) {
+			return
+		}
+
+		for _, symbol := range before.Symbols {
+			if _, ok := after.Symbol(symbol); !ok {
+				d.add(path, SymbolRemoved, symbol, "")
+			}
+		}
+		for _, symbol := range after.Symbols {
+			if _, ok := before.Symbol(symbol); !ok {
+				d.add(path, SymbolAdded, "", symbol)
+			}
+		}
+
+		if before.Default != after.Default || before.HasDefault != after.HasDefault {
+			d.add(path, DefaultChanged, before.Default, after.Default)
+		}
+	case *RecordSchema:
+		after := after.(*RecordSchema)
+		if d.named(path, before, after, before.Doc, after.Doc) {
+			d.record(path, before, after)
+		}
+	}
+}
+
+//named compares what records, enums and fixed have in common and returns false if the pair was already compared
+func (d *differ) named(path string, before, after NamedSchema, beforeDoc, afterDoc string) bool {
+	key := [2]string{before.FullName(), after.FullName()}
+	if d.compared[key] {
+		return false
+	}
+	d.compared[key] = true
+
+	if before.FullName() != after.FullName() {
+		d.add(path, NameChanged, before.FullName(), after.FullName())
+	}
+
+	if beforeDoc != afterDoc {
+		d.add(path, DocChanged, beforeDoc, afterDoc)
+	}
+
+	return true
+}
+
+func (d *differ) record(path string, before, after *RecordSchema) {
+	matched := make(map[string]bool)
+
+	for _, field := range after.Fields {
+		fieldPath := JoinPath(path, field.Name)
+
+		beforeField, ok := before.Field(field.Name)
+		if !ok {
+			if beforeField, ok = WriterField(field, before); ok {
+				d.add(fieldPath, FieldRenamed, beforeField.Name, field.Name)
+			}
+		}
+
+		if !ok {
+			d.add(fieldPath, FieldAdded, "", Describe(field.Type))
+			continue
+		}
+		matched[beforeField.Name] = true
+
+		if beforeField.Doc != field.Doc {
+			d.add(fieldPath, DocChanged, beforeField.Doc, field.Doc)
+		}
+
+		if beforeField.HasDefault != field.HasDefault || !sameDefault(beforeField.Default, field.Default) {
+			d.add(fieldPath, DefaultChanged, describeDefault(beforeField), describeDefault(field))
+		}
+
+		d.diff(fieldPath, beforeField.Type, field.Type)
+	}
+
+	for _, field := range before.Fields {
+		if !matched[field.Name] {
+			d.add(JoinPath(path, field.Name), FieldRemoved, Describe(field.Type), "")
+		}
+	}
+}
+
+func (d *differ) union(path string, before, after *UnionSchema) {
+	if Describe(before) != Describe(after) {
+		d.add(path, TypeChanged, Describe(before), Describe(after))
+	}
+
+	for _, afterBranch := range after.Types {
+		for _, beforeBranch := range before.Types {
+			if TypeName(beforeBranch) == TypeName(afterBranch) {
+				d.diff(path, beforeBranch, afterBranch)
+			}
+		}
+	}
+}
+
+//sameDefault compares the JSON forms of defaults, numbers by value so 1 and 1.0 are the same
+func sameDefault(before, after interface{}) bool {
+	switch before := before.(type) {
+	case json.Number:
+		after, ok := after.(json.Number)
+		if !ok {
+			return false
+		}
+
+		b, bok := new(big.Rat).SetString(string(before))
+		a, aok := new(big.Rat).SetString(string(after))
+		if !bok || !aok {
+			return before == after
+		}
+		return b.Cmp(a) == 0
+	case []interface{}:
+		after, ok := after.([]interface{})
+		if !ok || len(before) != len(after) {
+			return false
+		}
+
+		for i := range before {
+			if !sameDefault(before[i], after[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		after, ok := after.(map[string]interface{})
+		if !ok || len(before) != len(after) {
+			return false
+		}
+
+		for key, value := range before {
+			if afterValue, ok := after[key]; !ok || !sameDefault(value, afterValue) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(before, after)
+}
+
+func describeDefault(field *Field) string {
+	if !field.HasDefault {
+		return ""
+	}
+
+	b, err := json.Marshal(field.Default)
+	if err != nil {
+		return fmt.Sprintf("%v", field.Default)
+	}
+
+	return string(b)
+}
+
+//Describe is a short, single line description of a schema: the name of named schemas, array<items>, map<values>,
+//unions as a list of their branches and primitives with any logical type in parentheses
+func Describe(s Schema) string {
+	switch s := s.(type) {
+	case *PrimitiveSchema:
+		return describeLogical(string(s.Primitive), s.Logical)
+	case *FixedSchema:
+		return describeLogical(fmt.Sprintf("%v[%v]", s.FullName(), s.Size), s.Logical)
+	case *ArraySchema:
+		return "array<" + Describe(s.Items) + ">"
+	case *MapSchema:
+		return "map<" + Describe(s.Values) + ">"
+	case *UnionSchema:
+		branches := make([]string, len(s.Types))
+		for i, branch := range s.Types {
+			branches[i] = Describe(branch)
+		}
+		return "[" + strings.Join(branches, ", ") + "]"
+	default:
+		return TypeName(s)
+	}
+}
+
+func describeLogical(description string, logical *LogicalType) string {
+	if logical == nil {
+		return description
+	}
+
+	if logical.Name == Decimal {
+		return fmt.Sprintf("%v(decimal(%v,%v))", description, logical.Precision, logical.Scale)
+	}
+
+	return fmt.Sprintf("%v(%v)", description, logical.Name)
+}
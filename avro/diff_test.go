@@ -0,0 +1,86 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func diff(before, after string) []string {
+	var changes []string
+	for _, change := range Diff(MustParse(before), MustParse(after)) {
+		changes = append(changes, change.String())
+	}
+	return changes
+}
+
+func TestDiffIgnoresFormatting(t *testing.T) {
+	assert.Empty(t, diff(person, `{"namespace":"com.mm","name":"Person","type":"record","fields":[{"type":"string","name":"name"},{"name":"age","type":{"type":"int"}}]}`))
+}
+
+func TestDiffRecords(t *testing.T) {
+	before := `{"type": "record", "name": "Event", "doc": "an event", "fields": [
+	{"name": "id", "type": "int", "doc": "the id"},
+	{"name": "name", "type": "string"},
+	{"name": "legacy", "type": "string"},
+	{"name": "count", "type": "long", "default": 0},
+	{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN"]}},
+	{"name": "owner", "type": ["null", {"type": "record", "name": "Owner", "fields": [{"name": "email", "type": "string"}]}]},
+	{"name": "at", "type": "long"}
+]}`
+	after := `{"type": "record", "name": "Event", "doc": "an event that happened", "fields": [
+	{"name": "id", "type": "long", "doc": "the id"},
+	{"name": "fullName", "type": "string", "aliases": ["name"]},
+	{"name": "count", "type": "long", "default": 1},
+	{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "BLUE"], "default": "RED"}},
+	{"name": "owner", "type": ["null", {"type": "record", "name": "Owner", "fields": [{"name": "email", "type": ["null", "string"], "default": null}]}]},
+	{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+	{"name": "tags", "type": {"type": "array", "items": "string"}, "default": []}
+]}`
+
+	assert.Equal(t, []string{
+		`.: doc changed from "an event" to "an event that happened"`,
+		"id: type changed from int to long",
+		"fullName: field renamed from name",
+		"count: default changed from 0 to 1",
+		"color: symbol GREEN removed",
+		"color: symbol BLUE added",
+		"color: default changed from (none) to RED",
+		"owner.email: default changed from (none) to null",
+		"owner.email: type changed from string to [null, string]",
+		"at: type changed from long to long(timestamp-millis)",
+		"tags: field added with type array<string>",
+		"legacy: field removed, was string",
+	}, diff(before, after))
+}
+
+func TestDiffNested(t *testing.T) {
+	assert.Equal(t, []string{"[]{}: type changed from int to long"}, diff(`{"type": "array", "items": {"type": "map", "values": "int"}}`, `{"type": "array", "items": {"type": "map", "values": "long"}}`))
+	assert.Equal(t, []string{".: type changed from [null, int] to [null, int, string]"}, diff(`["null", "int"]`, `["null", "int", "string"]`))
+	assert.Equal(t, []string{".: type changed from Hash[16] to Hash[32]"}, diff(`{"type": "fixed", "name": "Hash", "size": 16}`, `{"type": "fixed", "name": "Hash", "size": 32}`))
+	assert.Equal(t, []string{".: name changed from a.R to b.R"}, diff(`{"type": "record", "name": "a.R", "fields": []}`, `{"type": "record", "name": "b.R", "fields": []}`))
+}
+
+func TestDiffRecursive(t *testing.T) {
+	list := `{"type": "record", "name": "List", "fields": [{"name": "value", "type": "int"}, {"name": "next", "type": ["null", "List"]}]}`
+	longList := `{"type": "record", "name": "List", "fields": [{"name": "value", "type": "long"}, {"name": "next", "type": ["null", "List"]}]}`
+	assert.Equal(t, []string{"value: type changed from int to long"}, diff(list, longList))
+}
+
+func TestDiffDefaultsByValue(t *testing.T) {
+	record := `{"type": "record", "name": "R", "fields": [
+	{"name": "ratio", "type": "double", "default": %v},
+	{"name": "limits", "type": {"type": "map", "values": {"type": "array", "items": "double"}}, "default": {"a": %v}}
+]}`
+
+	assert.Empty(t, diff(fmt.Sprintf(record, "1", "[2]"), fmt.Sprintf(record, "1.0", "[2e0]")))
+	assert.Equal(t, []string{
+		"ratio: default changed from 1 to 1.5",
+		"limits: default changed from {\"a\":[2]} to {\"a\":[2.5]}",
+	}, diff(fmt.Sprintf(record, "1", "[2]"), fmt.Sprintf(record, "1.5", "[2.5]")))
+}
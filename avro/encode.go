@@ -0,0 +1,499 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Marshal returns the avro binary encoding of v as schema.
+//
+//Values are nil for null, bool for boolean, any Go integer for int and long, float32 or float64 for float and double,
+//[]byte for bytes and fixed (or a byte array of the fixed size), and string for string and enum.  Records are structs
+//or maps with string keys, arrays are slices or arrays and maps are maps with string keys.  Pointers and interfaces are
+//followed.  A union is written as its first branch that can encode the value, nil choosing null.  json.Number is
+//accepted for numbers, time.Time for the date and timestamp logical types, time.Duration for the time ones and big.Rat
+//for decimals.  See TaggedUnion for choosing the branch of a union explicitly.
+//
+//Struct fields are matched to record fields by the name in their `avro:"name"` tag or their Go name, case
+//insensitively if there is no exact match.  Fields tagged `avro:"-"` are ignored.  Record fields missing from the value
+//are written with their default.
+func Marshal(schema Schema, v interface{}) ([]byte, error) {
+	return AppendBinary(nil, schema, v)
+}
+
+//AppendBinary appends the avro binary encoding of v as schema to dst, see Marshal
+func AppendBinary(dst []byte, schema Schema, v interface{}) ([]byte, error) {
+	return encode(dst, "", schema, reflect.ValueOf(v))
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	numberType   = reflect.TypeOf(json.Number(""))
+)
+
+func codecError(path string, format string, args ...interface{}) error {
+	if path == "" {
+		path = "."
+	}
+
+	return fmt.Errorf("%v: %v", path, fmt.Sprintf(format, args...))
+}
+
+//indirect follows pointers and interfaces, returning the zero Value for nil
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.IsValid() && (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+
+	return rv
+}
+
+func cannotEncode(path string, schema Schema, rv reflect.Value) error {
+	return codecError(path, "cannot encode %v as %v", rv.Type(), TypeName(schema))
+}
+
+func encode(buf []byte, path string, schema Schema, rv reflect.Value) ([]byte, error) {
+	rv = indirect(rv)
+
+	if union, ok := schema.(*UnionSchema); ok {
+		if rv.IsValid() && isTaggedUnion(rv.Type()) {
+			return encodeTaggedUnion(buf, path, union, rv)
+		}
+
+		return encodeUnion(buf, path, union, rv)
+	}
+
+	if !rv.IsValid() {
+		if schema.Type() == Null {
+			return buf, nil
+		}
+
+		return nil, codecError(path, "cannot encode nil as %v", TypeName(schema))
+	}
+
+	switch s := schema.(type) {
+	case *PrimitiveSchema:
+		return encodePrimitive(buf, path, s, rv)
+	case *RecordSchema:
+		return encodeRecord(buf, path, s, rv)
+	case *EnumSchema:
+		if rv.Kind() != reflect.String {
+			return nil, cannotEncode(path, schema, rv)
+		}
+
+		for i, symbol := range s.Symbols {
+			if symbol == rv.String() {
+				return appendLong(buf, int64(i)), nil
+			}
+		}
+
+		return nil, codecError(path, "%q is not a symbol of %v", rv.String(), s.FullName())
+	case *FixedSchema:
+		if rv.Type() == ratType {
+			b, err := decimalBytes(s.Logical, ratValue(rv), s.Size)
+			if err != nil {
+				return nil, codecError(path, "%v", err)
+			}
+			return append(buf, b...), nil
+		}
+
+		b, ok := byteValue(rv)
+		if !ok {
+			return nil, cannotEncode(path, schema, rv)
+		}
+
+		if len(b) != s.Size {
+			return nil, codecError(path, "%v is %v bytes, not %v", s.FullName(), s.Size, len(b))
+		}
+
+		return append(buf, b...), nil
+	case *ArraySchema:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, cannotEncode(path, schema, rv)
+		}
+
+		var err error
+		if rv.Len() > 0 {
+			buf = appendLong(buf, int64(rv.Len()))
+			for i := 0; i < rv.Len() && err == nil; i++ {
+				buf, err = encode(buf, JoinPath(path, "[]"), s.Items, rv.Index(i))
+			}
+		}
+
+		return appendLong(buf, 0), err
+	case *MapSchema:
+		if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+			return nil, cannotEncode(path, schema, rv)
+		}
+
+		//keys are sorted so the same map always has the same encoding
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		var err error
+		if len(keys) > 0 {
+			buf = appendLong(buf, int64(len(keys)))
+			for i := 0; i < len(keys) && err == nil; i++ {
+				buf = appendBytes(buf, []byte(keys[i].String()))
+				buf, err = encode(buf, JoinPath(path, "{}"), s.Values, rv.MapIndex(keys[i]))
+			}
+		}
+
+		return appendLong(buf, 0), err
+	}
+
+	return nil, codecError(path, "cannot encode %v", TypeName(schema))
+}
+
+func encodeUnion(buf []byte, path string, union *UnionSchema, rv reflect.Value) ([]byte, error) {
+	var firstErr error
+	for i, branch := range union.Types {
+		if rv.IsValid() == (branch.Type() == Null) {
+			continue
+		}
+
+		encoded, err := encode(appendLong(buf, int64(i)), path, branch, rv)
+		if err == nil {
+			return encoded, nil
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	//with one candidate branch its error says more than that no branch matched
+	candidates := len(union.Types)
+	if union.Nullable() {
+		candidates--
+	}
+
+	if candidates == 1 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	if !rv.IsValid() {
+		return nil, codecError(path, "union %v has no null branch for nil", Describe(union))
+	}
+
+	return nil, codecError(path, "no branch of union %v can encode %v", Describe(union), rv.Type())
+}
+
+func encodePrimitive(buf []byte, path string, s *PrimitiveSchema, rv reflect.Value) ([]byte, error) {
+	switch s.Primitive {
+	case Boolean:
+		if rv.Kind() == reflect.Bool {
+			return appendBoolean(buf, rv.Bool()), nil
+		}
+	case Int, Long:
+		n, ok, err := integerValue(s, rv)
+		if err != nil {
+			return nil, codecError(path, "%v", err)
+		}
+
+		if ok && s.Primitive == Int && (n < math.MinInt32 || n > math.MaxInt32) {
+			return nil, codecError(path, "%v overflows an int", n)
+		}
+
+		if ok {
+			return appendLong(buf, n), nil
+		}
+	case Float, Double:
+		f, ok, err := floatValue(rv)
+		if err != nil {
+			return nil, codecError(path, "%v", err)
+		}
+
+		if ok && s.Primitive == Float {
+			return appendFloat(buf, float32(f)), nil
+		}
+
+		if ok {
+			return appendDouble(buf, f), nil
+		}
+	case Bytes:
+		if rv.Type() == ratType {
+			b, err := decimalBytes(s.Logical, ratValue(rv), 0)
+			if err != nil {
+				return nil, codecError(path, "%v", err)
+			}
+			return appendBytes(buf, b), nil
+		}
+
+		if b, ok := byteValue(rv); ok {
+			return appendBytes(buf, b), nil
+		}
+	case String:
+		if rv.Kind() == reflect.String && rv.Type() != numberType {
+			return appendBytes(buf, []byte(rv.String())), nil
+		}
+	}
+
+	return nil, cannotEncode(path, s, rv)
+}
+
+func integerValue(s *PrimitiveSchema, rv reflect.Value) (int64, bool, error) {
+	logical := ""
+	if s.Logical != nil {
+		logical = s.Logical.Name
+	}
+
+	switch {
+	case rv.Type() == timeType:
+		return fromTime(logical, rv.Interface().(time.Time))
+	case rv.Type() == durationType && (logical == TimeMillis || logical == TimeMicros):
+		d := rv.Interface().(time.Duration)
+		if logical == TimeMillis {
+			return int64(d / time.Millisecond), true, nil
+		}
+		return int64(d / time.Microsecond), true, nil
+	case rv.Type() == numberType:
+		n, err := rv.Interface().(json.Number).Int64()
+		return n, err == nil, err
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, false, fmt.Errorf("%v overflows a long", rv.Uint())
+		}
+		return int64(rv.Uint()), true, nil
+	}
+
+	return 0, false, nil
+}
+
+func fromTime(logical string, t time.Time) (int64, bool, error) {
+	switch logical {
+	case LocalTimestampMillis, LocalTimestampMicros:
+		//local timestamps are the wall clock time, whatever the zone
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+
+	switch logical {
+	case Date:
+		days := t.Unix() / 86400
+		if t.Unix() < 0 && t.Unix()%86400 != 0 {
+			days--
+		}
+		return days, true, nil
+	case TimestampMillis, LocalTimestampMillis:
+		return t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond), true, nil
+	case TimestampMicros, LocalTimestampMicros:
+		return t.Unix()*1000000 + int64(t.Nanosecond())/int64(time.Microsecond), true, nil
+	}
+
+	return 0, false, fmt.Errorf("time.Time needs a date or timestamp logical type")
+}
+
+func floatValue(rv reflect.Value) (float64, bool, error) {
+	if rv.Type() == numberType {
+		f, err := rv.Interface().(json.Number).Float64()
+		return f, err == nil, err
+	}
+
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true, nil
+	}
+
+	return 0, false, nil
+}
+
+func byteValue(rv reflect.Value) ([]byte, bool) {
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, false
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice:
+		return rv.Bytes(), true
+	case reflect.Array:
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return b, true
+	}
+
+	return nil, false
+}
+
+func encodeRecord(buf []byte, path string, s *RecordSchema, rv reflect.Value) ([]byte, error) {
+	var field func(name string) (reflect.Value, bool)
+
+	switch {
+	case rv.Kind() == reflect.Struct:
+		field = func(name string) (reflect.Value, bool) {
+			return structField(rv, name)
+		}
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		field = func(name string) (reflect.Value, bool) {
+			value := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+			return value, value.IsValid()
+		}
+	default:
+		return nil, cannotEncode(path, s, rv)
+	}
+
+	var err error
+	for _, f := range s.Fields {
+		fieldPath := JoinPath(path, f.Name)
+
+		value, ok := field(f.Name)
+		if !ok {
+			if !f.HasDefault {
+				return nil, codecError(fieldPath, "missing field %v, which has no default", f.Name)
+			}
+			value = reflect.ValueOf(defaultValue(f.Type, f.Default))
+		}
+
+		if buf, err = encode(buf, fieldPath, f.Type, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf, nil
+}
+
+//defaultValue converts a default from its JSON form to the form Marshal takes
+func defaultValue(s Schema, v interface{}) interface{} {
+	switch s := s.(type) {
+	case *PrimitiveSchema:
+		if str, ok := v.(string); ok && s.Primitive == Bytes {
+			b, _ := latin1(str)
+			return b
+		}
+	case *FixedSchema:
+		if str, ok := v.(string); ok {
+			b, _ := latin1(str)
+			return b
+		}
+	case *ArraySchema:
+		if items, ok := v.([]interface{}); ok {
+			converted := make([]interface{}, len(items))
+			for i, item := range items {
+				converted[i] = defaultValue(s.Items, item)
+			}
+			return converted
+		}
+	case *MapSchema:
+		if values, ok := v.(map[string]interface{}); ok {
+			converted := make(map[string]interface{}, len(values))
+			for key, value := range values {
+				converted[key] = defaultValue(s.Values, value)
+			}
+			return converted
+		}
+	case *RecordSchema:
+		if values, ok := v.(map[string]interface{}); ok {
+			converted := make(map[string]interface{}, len(values))
+			for _, field := range s.Fields {
+				if value, ok := values[field.Name]; ok {
+					converted[field.Name] = defaultValue(field.Type, value)
+				}
+			}
+			return converted
+		}
+	case *UnionSchema:
+		if len(s.Types) > 0 && defaultMatches(s.Types[0], v) {
+			return defaultValue(s.Types[0], v)
+		}
+	}
+
+	return v
+}
+
+//defaultMatches is whether the JSON form of a default could be a value of s
+func defaultMatches(s Schema, v interface{}) bool {
+	switch v.(type) {
+	case nil:
+		return s.Type() == Null
+	case bool:
+		return s.Type() == Boolean
+	case json.Number:
+		switch s.Type() {
+		case Int, Long, Float, Double:
+			return true
+		}
+	case string:
+		switch s.Type() {
+		case String, Bytes, Enum, Fixed:
+			return true
+		}
+	case []interface{}:
+		return s.Type() == Array
+	case map[string]interface{}:
+		return s.Type() == Map || isRecord(s)
+	}
+
+	return false
+}
+
+type structFieldIndex struct {
+	exact  map[string]int
+	folded map[string]int
+}
+
+var structFields sync.Map
+
+//structField finds the field of a struct for a record field, by avro tag or Go name and then case insensitively
+func structField(rv reflect.Value, name string) (reflect.Value, bool) {
+	index := fieldIndex(rv.Type())
+
+	i, ok := index.exact[name]
+	if !ok {
+		i, ok = index.folded[strings.ToLower(name)]
+	}
+
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	return rv.Field(i), true
+}
+
+func fieldIndex(t reflect.Type) *structFieldIndex {
+	if cached, ok := structFields.Load(t); ok {
+		return cached.(*structFieldIndex)
+	}
+
+	index := &structFieldIndex{exact: make(map[string]int), folded: make(map[string]int)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("avro"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		index.exact[name] = i
+		if _, ok := index.folded[strings.ToLower(name)]; !ok {
+			index.folded[strings.ToLower(name)] = i
+		}
+	}
+
+	structFields.Store(t, index)
+	return index
+}
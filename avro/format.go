@@ -0,0 +1,182 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Format returns the JSON text of s with all of its attributes, unlike Canonical, so Parse returns an equivalent schema.
+//Named schemas are defined where they are first used and referred to by their full name after that.
+func Format(s Schema) string {
+	var b strings.Builder
+	writeFormat(&b, s, "", make(map[string]bool))
+	return b.String()
+}
+
+//jsonText writes v as JSON without the html escaping encoding/json does by default
+func jsonText(v interface{}) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "null"
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+//attribute writes a "key":value member of an object that already has members
+func attribute(b *strings.Builder, key, value string) {
+	b.WriteString("," + quote(key) + ":" + value)
+}
+
+func writeProps(b *strings.Builder, props Properties) {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		attribute(b, key, jsonText(props[key]))
+	}
+}
+
+func writeLogical(b *strings.Builder, logical *LogicalType) {
+	if logical == nil {
+		return
+	}
+
+	attribute(b, "logicalType", quote(logical.Name))
+	if logical.Name == Decimal {
+		attribute(b, "precision", strconv.Itoa(logical.Precision))
+		attribute(b, "scale", strconv.Itoa(logical.Scale))
+	}
+}
+
+func writeFormat(b *strings.Builder, s Schema, namespace string, defined map[string]bool) {
+	switch s := s.(type) {
+	case *PrimitiveSchema:
+		if s.Logical == nil && len(s.Props) == 0 {
+			b.WriteString(quote(string(s.Primitive)))
+			return
+		}
+
+		b.WriteString(`{"type":` + quote(string(s.Primitive)))
+		writeLogical(b, s.Logical)
+		writeProps(b, s.Props)
+		b.WriteByte('}')
+	case *UnionSchema:
+		b.WriteByte('[')
+		for i, branch := range s.Types {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeFormat(b, branch, namespace, defined)
+		}
+		b.WriteByte(']')
+	case *ArraySchema:
+		b.WriteString(`{"type":"array","items":`)
+		writeFormat(b, s.Items, namespace, defined)
+		writeProps(b, s.Props)
+		b.WriteByte('}')
+	case *MapSchema:
+		b.WriteString(`{"type":"map","values":`)
+		writeFormat(b, s.Values, namespace, defined)
+		writeProps(b, s.Props)
+		b.WriteByte('}')
+	case NamedSchema:
+		writeNamed(b, s, namespace, defined)
+	}
+}
+
+func writeNamed(b *strings.Builder, s NamedSchema, enclosing string, defined map[string]bool) {
+	name := s.FullName()
+	if defined[name] {
+		b.WriteString(quote(name))
+		return
+	}
+	defined[name] = true
+
+	namespace := ""
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		namespace = name[:dot]
+	}
+
+	b.WriteString(`{"type":` + quote(string(s.Type())) + `,"name":` + quote(unqualified(name)))
+	if namespace != enclosing {
+		attribute(b, "namespace", quote(namespace))
+	}
+
+	var doc string
+	var aliases []string
+	var props Properties
+	switch s := s.(type) {
+	case *RecordSchema:
+		doc, aliases, props = s.Doc, s.Aliases, s.Props
+	case *EnumSchema:
+		doc, aliases, props = s.Doc, s.Aliases, s.Props
+	case *FixedSchema:
+		doc, aliases, props = s.Doc, s.Aliases, s.Props
+	}
+
+	if doc != "" {
+		attribute(b, "doc", quote(doc))
+	}
+
+	if len(aliases) > 0 {
+		attribute(b, "aliases", jsonText(aliases))
+	}
+
+	switch s := s.(type) {
+	case *RecordSchema:
+		b.WriteString(`,"fields":[`)
+		for i, field := range s.Fields {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeField(b, field, namespace, defined)
+		}
+		b.WriteByte(']')
+	case *EnumSchema:
+		attribute(b, "symbols", jsonText(s.Symbols))
+		if s.HasDefault {
+			attribute(b, "default", quote(s.Default))
+		}
+	case *FixedSchema:
+		attribute(b, "size", strconv.Itoa(s.Size))
+		writeLogical(b, s.Logical)
+	}
+
+	writeProps(b, props)
+	b.WriteByte('}')
+}
+
+func writeField(b *strings.Builder, field *Field, namespace string, defined map[string]bool) {
+	b.WriteString(`{"name":` + quote(field.Name) + `,"type":`)
+	writeFormat(b, field.Type, namespace, defined)
+
+	if field.Doc != "" {
+		attribute(b, "doc", quote(field.Doc))
+	}
+
+	if field.HasDefault {
+		attribute(b, "default", jsonText(field.Default))
+	}
+
+	if field.Order != "" && field.Order != Ascending {
+		attribute(b, "order", quote(string(field.Order)))
+	}
+
+	if len(field.Aliases) > 0 {
+		attribute(b, "aliases", jsonText(field.Aliases))
+	}
+
+	writeProps(b, field.Props)
+	b.WriteByte('}')
+}
@@ -0,0 +1,39 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	schema := `{"type": "record", "name": "Event", "namespace": "com.mm", "doc": "things <that> happen", "aliases": ["Happening"], "owner": "ads", "fields": [
+		{"name": "id", "type": {"type": "string", "logicalType": "uuid"}, "doc": "the id", "order": "descending"},
+		{"name": "cost", "type": {"type": "fixed", "name": "Cost", "size": 8, "logicalType": "decimal", "precision": 10, "scale": 2}},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"], "default": "A"}, "default": "B", "aliases": ["type"]},
+		{"name": "local", "type": ["null", {"type": "record", "name": "Local", "namespace": "", "fields": []}], "default": null},
+		{"name": "tags", "type": {"type": "map", "values": {"type": "array", "items": "Kind"}}, "default": {"a": ["A"]}},
+		{"name": "next", "type": ["null", "Event"], "default": null}
+	]}`
+
+	formatted := Format(MustParse(schema))
+	assert.Equal(t, `{"type":"record","name":"Event","namespace":"com.mm","doc":"things <that> happen","aliases":["Happening"],"fields":[`+
+		`{"name":"id","type":{"type":"string","logicalType":"uuid"},"doc":"the id","order":"descending"},`+
+		`{"name":"cost","type":{"type":"fixed","name":"Cost","size":8,"logicalType":"decimal","precision":10,"scale":2}},`+
+		`{"name":"kind","type":{"type":"enum","name":"Kind","symbols":["A","B"],"default":"A"},"default":"B","aliases":["type"]},`+
+		`{"name":"local","type":["null",{"type":"record","name":"Local","namespace":"","fields":[]}],"default":null},`+
+		`{"name":"tags","type":{"type":"map","values":{"type":"array","items":"com.mm.Kind"}},"default":{"a":["A"]}},`+
+		`{"name":"next","type":["null","com.mm.Event"],"default":null}`+
+		`],"owner":"ads"}`, formatted)
+
+	reparsed, err := Parse(formatted)
+	require.NoError(t, err)
+	assert.Equal(t, formatted, Format(reparsed))
+	assert.Equal(t, Canonical(MustParse(schema)), Canonical(reparsed))
+	assert.Empty(t, Diff(MustParse(schema), reparsed))
+}
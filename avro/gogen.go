@@ -0,0 +1,409 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//GoOptions configure GenerateGo
+type GoOptions struct {
+	//Package is the name of the generated package
+	Package string
+	//Name prefixes the generated constants and names the top level type if the schema is not named.  It defaults to
+	//the Go name of a named schema and Value otherwise.
+	Name string
+	//Subject, Version and ID are where the schema is registered.  They are generated as constants when they are set.
+	Subject string
+	Version int
+	ID      uint32
+	//Source is the text of the schema, generated as a constant for the sr Serializer and Deserializer.  It should be
+	//the text that is registered, which Canonical is not.
+	Source string
+}
+
+//GenerateGo returns the source of a Go file with types for schema that Marshal and Unmarshal use.  Records are structs
+//with `avro` field tags, enums are string types with a constant for each symbol and fixed are byte arrays.  Unions of
+//null and one type are pointers and other unions are TaggedUnion structs.  Logical types are time.Time,
+//time.Duration and *big.Rat as Marshal takes them.
+func GenerateGo(schema Schema, options GoOptions) ([]byte, error) {
+	if options.Package == "" {
+		return nil, fmt.Errorf("no package name for the generated code")
+	}
+
+	g := &goGenerator{taken: make(map[string]bool), types: make(map[string]string), imports: make(map[string]bool)}
+
+	name := options.Name
+	if name == "" {
+		name = "Value"
+		if named, ok := schema.(NamedSchema); ok {
+			name = goName(unqualified(named.FullName()))
+		}
+	}
+
+	//the constants are declared before the types, which fall back to other names if theirs are taken
+	reserved := map[string]bool{"Subject": options.Subject != "", "Version": options.Version > 0, "SchemaID": options.ID > 0, "Schema": options.Source != ""}
+	for suffix, generated := range reserved {
+		if generated {
+			g.taken[name+suffix] = true
+		}
+	}
+	g.typeOf(schema, name)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by sr gen go. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %v\n\n", options.Package)
+
+	if len(g.imports) > 0 {
+		//the standard library is grouped before other packages
+		var std, other []string
+		for path := range g.imports {
+			if strings.Contains(strings.Split(path, "/")[0], ".") {
+				other = append(other, strconv.Quote(path))
+			} else {
+				std = append(std, strconv.Quote(path))
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(other)
+		fmt.Fprintf(&b, "import (\n%v\n\n%v\n)\n\n", strings.Join(std, "\n"), strings.Join(other, "\n"))
+	}
+
+	var constants strings.Builder
+	if options.Subject != "" {
+		fmt.Fprintf(&constants, "// %vSubject is the subject the schema is registered on\n%vSubject = %q\n", name, name, options.Subject)
+	}
+	if options.Version > 0 {
+		fmt.Fprintf(&constants, "// %vVersion is the version of the schema on the subject\n%vVersion = %v\n", name, name, options.Version)
+	}
+	if options.ID > 0 {
+		fmt.Fprintf(&constants, "// %vSchemaID is the id the registry gave the schema\n%vSchemaID = %v\n", name, name, options.ID)
+	}
+	if options.Source != "" {
+		fmt.Fprintf(&constants, "// %vSchema is the text of the schema\n%vSchema = %v\n", name, name, goString(options.Source))
+	}
+	if constants.Len() > 0 {
+		fmt.Fprintf(&b, "const (\n%v)\n", constants.String())
+	}
+
+	for _, decl := range g.decls {
+		b.WriteString("\n" + decl)
+	}
+
+	return format.Source(b.Bytes())
+}
+
+//goString is s as a raw string literal, if it can be one
+func goString(s string) string {
+	if strings.Contains(s, "`") || strings.Contains(s, "\r") {
+		return strconv.Quote(s)
+	}
+
+	return "`" + s + "`"
+}
+
+var initialisms = map[string]bool{
+	"api": true, "http": true, "id": true, "ip": true, "json": true, "sql": true, "ttl": true, "uri": true,
+	"url": true, "uuid": true, "xml": true,
+}
+
+//goName turns an avro name like user_id, userId or USER_ID into an exported Go name like UserID
+func goName(name string) string {
+	var words []string
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		start := 0
+		runes := []rune(part)
+		for i := 1; i < len(runes); i++ {
+			if unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i]) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+
+	var b strings.Builder
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		switch {
+		case initialisms[lower]:
+			b.WriteString(strings.ToUpper(word))
+		case word == strings.ToUpper(word):
+			runes := []rune(lower)
+			b.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+		default:
+			runes := []rune(word)
+			b.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+		}
+	}
+
+	goName := b.String()
+	if goName == "" || unicode.IsDigit([]rune(goName)[0]) {
+		goName = "X" + goName
+	}
+
+	return goName
+}
+
+//docComment is a comment of the lines of doc
+func docComment(doc string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			b.WriteString("//\n")
+			continue
+		}
+		b.WriteString("// " + line + "\n")
+	}
+
+	return b.String()
+}
+
+type goGenerator struct {
+	//taken are the Go names that are declared
+	taken map[string]bool
+	//types are the Go names of the named schemas by full name
+	types   map[string]string
+	imports map[string]bool
+	decls   []string
+}
+
+//declare reserves a Go name based on name, or alternative if name is taken, numbering it if both are
+func (g *goGenerator) declare(name, alternative string) string {
+	candidate := name
+	if g.taken[candidate] && alternative != "" {
+		candidate = alternative
+	}
+
+	for i := 2; g.taken[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+
+	g.taken[candidate] = true
+	return candidate
+}
+
+//reserve adds a declaration that is filled in later, so types are declared in the order they are first used
+func (g *goGenerator) reserve() int {
+	g.decls = append(g.decls, "")
+	return len(g.decls) - 1
+}
+
+//typeOf returns the Go type for s, declaring the types it needs.  context is the name of unnamed types that need a
+//declaration, like unions.
+func (g *goGenerator) typeOf(s Schema, context string) string {
+	switch s := s.(type) {
+	case *PrimitiveSchema:
+		return g.primitive(s)
+	case *RecordSchema:
+		return g.record(s)
+	case *EnumSchema:
+		return g.enum(s)
+	case *FixedSchema:
+		return g.fixed(s)
+	case *ArraySchema:
+		return "[]" + g.typeOf(s.Items, context+"Item")
+	case *MapSchema:
+		return "map[string]" + g.typeOf(s.Values, context+"Value")
+	case *UnionSchema:
+		return g.union(s, context)
+	}
+
+	return "interface{}"
+}
+
+func (g *goGenerator) primitive(s *PrimitiveSchema) string {
+	if s.Logical != nil {
+		switch s.Logical.Name {
+		case Date, TimestampMillis, TimestampMicros, LocalTimestampMillis, LocalTimestampMicros:
+			g.imports["time"] = true
+			return "time.Time"
+		case TimeMillis, TimeMicros:
+			g.imports["time"] = true
+			return "time.Duration"
+		case Decimal:
+			g.imports["math/big"] = true
+			return "*big.Rat"
+		}
+	}
+
+	switch s.Primitive {
+	case Boolean:
+		return "bool"
+	case Int:
+		return "int32"
+	case Long:
+		return "int64"
+	case Float:
+		return "float32"
+	case Double:
+		return "float64"
+	case Bytes:
+		return "[]byte"
+	case String:
+		return "string"
+	}
+
+	return "interface{}"
+}
+
+//named returns the Go name of a named schema and whether it still has to be declared
+func (g *goGenerator) named(s NamedSchema) (string, bool) {
+	if name, ok := g.types[s.FullName()]; ok {
+		return name, false
+	}
+
+	name := g.declare(goName(unqualified(s.FullName())), goName(s.FullName()))
+	g.types[s.FullName()] = name
+	return name, true
+}
+
+func (g *goGenerator) record(s *RecordSchema) string {
+	name, declare := g.named(s)
+	if !declare {
+		return name
+	}
+
+	//the type is declared before its fields so recursive references find it
+	index := g.reserve()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %v is the avro %v %v\n", name, s.Type(), s.FullName())
+	if s.Doc != "" {
+		b.WriteString("//\n" + docComment(s.Doc))
+	}
+	fmt.Fprintf(&b, "type %v struct {\n", name)
+
+	fields := make(map[string]bool)
+	for _, field := range s.Fields {
+		fieldName := uniqueField(fields, goName(field.Name))
+		if field.Doc != "" {
+			b.WriteString(docComment(field.Doc))
+		}
+		fmt.Fprintf(&b, "%v %v `avro:%q`\n", fieldName, g.typeOf(field.Type, name+fieldName), field.Name)
+	}
+	b.WriteString("}\n")
+
+	g.decls[index] = b.String()
+	return name
+}
+
+func uniqueField(fields map[string]bool, name string) string {
+	candidate := name
+	for i := 2; fields[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+
+	fields[candidate] = true
+	return candidate
+}
+
+func (g *goGenerator) enum(s *EnumSchema) string {
+	name, declare := g.named(s)
+	if !declare {
+		return name
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %v is the avro enum %v\n", name, s.FullName())
+	if s.Doc != "" {
+		b.WriteString("//\n" + docComment(s.Doc))
+	}
+	fmt.Fprintf(&b, "type %v string\n\n// Symbols of %v\nconst (\n", name, name)
+	for _, symbol := range s.Symbols {
+		fmt.Fprintf(&b, "%v %v = %q\n", g.declare(name+goName(symbol), ""), name, symbol)
+	}
+	b.WriteString(")\n\n")
+
+	fmt.Fprintf(&b, "// AvroSymbols are the symbols of %v in order, for avro.SchemaOf\n", name)
+	fmt.Fprintf(&b, "func (%v) AvroSymbols() []string {\nreturn []string{", name)
+	for i, symbol := range s.Symbols {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.Quote(symbol))
+	}
+	b.WriteString("}\n}\n")
+
+	g.decls = append(g.decls, b.String())
+	return name
+}
+
+func (g *goGenerator) fixed(s *FixedSchema) string {
+	if s.Logical != nil && s.Logical.Name == Decimal {
+		g.imports["math/big"] = true
+		return "*big.Rat"
+	}
+
+	name, declare := g.named(s)
+	if !declare {
+		return name
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %v is the avro fixed %v\n", name, s.FullName())
+	if s.Doc != "" {
+		b.WriteString("//\n" + docComment(s.Doc))
+	}
+	fmt.Fprintf(&b, "type %v [%v]byte\n", name, s.Size)
+
+	g.decls = append(g.decls, b.String())
+	return name
+}
+
+func (g *goGenerator) union(s *UnionSchema, context string) string {
+	var branches []Schema
+	for _, branch := range s.Types {
+		if branch.Type() != Null {
+			branches = append(branches, branch)
+		}
+	}
+
+	if len(branches) == 0 {
+		return "interface{}"
+	}
+
+	if len(branches) == 1 {
+		t := g.typeOf(branches[0], context)
+		if !s.Nullable() || strings.HasPrefix(t, "*") || t == "interface{}" {
+			return t
+		}
+		return "*" + t
+	}
+
+	name := g.declare(context, "")
+	index := g.reserve()
+	g.imports["github.com/MediaMath/sr/avro"] = true
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %v is the avro union %v, it is null if no field is set\n", name, Describe(s))
+	fmt.Fprintf(&b, "type %v struct {\navro.TaggedUnion\n", name)
+
+	fields := make(map[string]bool)
+	for _, branch := range branches {
+		t := g.typeOf(branch, name+goName(string(branch.Type())))
+
+		fieldName := goName(string(branch.Type()))
+		if _, ok := branch.(NamedSchema); ok && !strings.HasPrefix(t, "*") {
+			fieldName = t
+		}
+
+		if !strings.HasPrefix(t, "*") {
+			t = "*" + t
+		}
+		fmt.Fprintf(&b, "%v %v `avro:%q`\n", uniqueField(fields, fieldName), t, TypeName(branch))
+	}
+	b.WriteString("}\n")
+
+	g.decls[index] = b.String()
+	return name
+}
@@ -0,0 +1,132 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"go/ast"
+	"go/importer"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoName(t *testing.T) {
+	names := map[string]string{
+		"name":       "Name",
+		"user_id":    "UserID",
+		"userId":     "UserID",
+		"DARK_BLUE":  "DarkBlue",
+		"httpURL":    "HTTPURL",
+		"com.mm.Foo": "ComMmFoo",
+		"2fa":        "X2fa",
+		"_":          "X",
+	}
+
+	for name, expected := range names {
+		assert.Equal(t, expected, goName(name), name)
+	}
+}
+
+//squash collapses runs of whitespace so generated code can be compared without the alignment gofmt adds
+func squash(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+const generated = `{"type": "record", "name": "Event", "namespace": "com.mm", "doc": "Something that happened", "fields": [
+	{"name": "event_id", "type": {"type": "string", "logicalType": "uuid"}},
+	{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+	{"name": "amount", "type": ["null", {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}]},
+	{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "DARK_BLUE"]}},
+	{"name": "hash", "type": ["null", {"type": "fixed", "name": "Hash", "size": 16}], "doc": "md5 of the body"},
+	{"name": "payload", "type": ["null", "string", {"type": "record", "name": "Person", "namespace": "com.other", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "friends", "type": {"type": "array", "items": "Person"}}
+	]}]},
+	{"name": "tags", "type": {"type": "map", "values": ["int", "string"]}},
+	{"name": "other", "type": {"type": "record", "name": "Color", "namespace": "com.other", "fields": []}}
+]}`
+
+func TestGenerateGo(t *testing.T) {
+	code, err := GenerateGo(MustParse(generated), GoOptions{Package: "events", Subject: "events-value", Version: 2, ID: 7, Source: generated})
+	require.NoError(t, err)
+
+	_, err = goparser.ParseFile(token.NewFileSet(), "events.go", code, goparser.ParseComments)
+	require.NoError(t, err, "%s", code)
+
+	source := squash(string(code))
+	for _, expected := range []string{
+		"// Code generated by sr gen go. DO NOT EDIT. package events",
+		`import ( "math/big" "time" "github.com/MediaMath/sr/avro" )`,
+		`EventSubject = "events-value"`,
+		"EventVersion = 2",
+		"EventSchemaID = 7",
+		"EventSchema = `{\"type\": \"record\"",
+		"// Event is the avro record com.mm.Event // // Something that happened type Event struct {",
+		"EventID string `avro:\"event_id\"`",
+		"At time.Time `avro:\"at\"`",
+		"Amount *big.Rat `avro:\"amount\"`",
+		"Color Color `avro:\"color\"`",
+		"// md5 of the body Hash *Hash `avro:\"hash\"`",
+		"Payload EventPayload `avro:\"payload\"`",
+		"Tags map[string]EventTagsValue `avro:\"tags\"`",
+		"Other ComOtherColor `avro:\"other\"`",
+		`type Color string // Symbols of Color const ( ColorRed Color = "RED" ColorDarkBlue Color = "DARK_BLUE" )`,
+		`func (Color) AvroSymbols() []string { return []string{"RED", "DARK_BLUE"} }`,
+		"type Hash [16]byte",
+		"type EventPayload struct { avro.TaggedUnion String *string `avro:\"string\"` Person *Person `avro:\"com.other.Person\"` }",
+		"type Person struct { Name string `avro:\"name\"` Friends []Person `avro:\"friends\"` }",
+		"type EventTagsValue struct { avro.TaggedUnion Int *int32 `avro:\"int\"` String *string `avro:\"string\"` }",
+		"type ComOtherColor struct { }",
+	} {
+		assert.Contains(t, source, expected)
+	}
+
+	assert.True(t, strings.Index(source, "type Event struct") < strings.Index(source, "type Color string"), "types are in the order they are used")
+}
+
+func TestGenerateGoUnnamed(t *testing.T) {
+	code, err := GenerateGo(MustParse(`["null", "long", "string"]`), GoOptions{Package: "ids", Source: "`\"long\"`"})
+	require.NoError(t, err)
+
+	source := squash(string(code))
+	assert.Contains(t, source, "type Value struct { avro.TaggedUnion Long *int64 `avro:\"long\"` String *string `avro:\"string\"` }")
+	assert.Contains(t, source, "ValueSchema = \"`\\\"long\\\"`\"", "a schema with a backtick is quoted")
+	assert.NotContains(t, source, "ValueSubject")
+
+	code, err = GenerateGo(MustParse(`"string"`), GoOptions{Package: "names", Name: "Name"})
+	require.NoError(t, err)
+	assert.Equal(t, "// Code generated by sr gen go. DO NOT EDIT. package names", squash(string(code)))
+
+	_, err = GenerateGo(MustParse(`"string"`), GoOptions{})
+	assert.Error(t, err)
+}
+
+func TestGenerateGoConstantNamesAreTaken(t *testing.T) {
+	schema := `{"type":"record","name":"Event","fields":[
+		{"name":"schema","type":["null","string","long"]},
+		{"name":"subject","type":{"type":"enum","name":"EventSubject","symbols":["A"]}},
+		{"name":"version","type":["null","int","string"]}
+	]}`
+	code, err := GenerateGo(MustParse(schema), GoOptions{Package: "events", Subject: "events-value", Version: 1, ID: 7, Source: schema})
+	require.NoError(t, err)
+
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "events.go", code, 0)
+	require.NoError(t, err, "%s", code)
+
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = config.Check("events", fset, []*ast.File{file}, nil)
+	require.NoError(t, err, "%s", code)
+
+	source := squash(string(code))
+	assert.Contains(t, source, "Schema EventSchema2 `avro:\"schema\"`")
+	assert.Contains(t, source, "Subject EventSubject2 `avro:\"subject\"`")
+	assert.Contains(t, source, "Version EventVersion2 `avro:\"version\"`")
+}
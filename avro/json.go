@@ -0,0 +1,340 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+//Position is a location in the text of a schema.  Line and Column start at 1, Offset is in bytes and starts at 0.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%v:%v", p.Line, p.Column)
+}
+
+//IsValid is false for the zero Position, which schemas built in code rather than parsed have
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+type jsonKind int
+
+const (
+	jsonNull jsonKind = iota
+	jsonBool
+	jsonNumber
+	jsonString
+	jsonArray
+	jsonObject
+)
+
+func (k jsonKind) String() string {
+	return [...]string{"null", "boolean", "number", "string", "array", "object"}[k]
+}
+
+//jsonValue is a parsed JSON value that remembers where it came from and the order of object members
+type jsonValue struct {
+	kind    jsonKind
+	pos     Position
+	text    string
+	boolean bool
+	items   []*jsonValue
+	members []jsonMember
+}
+
+type jsonMember struct {
+	key    string
+	keyPos Position
+	value  *jsonValue
+}
+
+func (v *jsonValue) member(key string) (*jsonValue, bool) {
+	for _, m := range v.members {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+
+	return nil, false
+}
+
+//interfaceValue converts v to the values encoding/json produces with UseNumber
+func (v *jsonValue) interfaceValue() interface{} {
+	switch v.kind {
+	case jsonBool:
+		return v.boolean
+	case jsonNumber:
+		return json.Number(v.text)
+	case jsonString:
+		return v.text
+	case jsonArray:
+		items := make([]interface{}, len(v.items))
+		for i, item := range v.items {
+			items[i] = item.interfaceValue()
+		}
+		return items
+	case jsonObject:
+		members := make(map[string]interface{}, len(v.members))
+		for _, m := range v.members {
+			members[m.key] = m.value.interfaceValue()
+		}
+		return members
+	default:
+		return nil
+	}
+}
+
+type jsonParser struct {
+	data       string
+	offset     int
+	lineStarts []int
+}
+
+func parseJSON(data string) (*jsonValue, error) {
+	p := &jsonParser{data: data, lineStarts: []int{0}}
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\n' {
+			p.lineStarts = append(p.lineStarts, i+1)
+		}
+	}
+
+	p.skipSpace()
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.offset < len(p.data) {
+		return nil, p.errorf(p.offset, "unexpected %q after the end of the schema", p.data[p.offset])
+	}
+
+	return value, nil
+}
+
+func (p *jsonParser) position(offset int) Position {
+	line := sort.Search(len(p.lineStarts), func(i int) bool { return p.lineStarts[i] > offset })
+	return Position{Offset: offset, Line: line, Column: offset - p.lineStarts[line-1] + 1}
+}
+
+func (p *jsonParser) errorf(offset int, format string, args ...interface{}) error {
+	return &ParseError{Pos: p.position(offset), Message: "invalid JSON: " + fmt.Sprintf(format, args...)}
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.offset < len(p.data) {
+		switch p.data[p.offset] {
+		case ' ', '\t', '\n', '\r':
+			p.offset++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) value() (*jsonValue, error) {
+	if p.offset >= len(p.data) {
+		return nil, p.errorf(p.offset, "unexpected end of input")
+	}
+
+	start := p.offset
+	switch c := p.data[p.offset]; {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"':
+		text, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonValue{kind: jsonString, pos: p.position(start), text: text}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case p.literal("true"):
+		return &jsonValue{kind: jsonBool, pos: p.position(start), boolean: true}, nil
+	case p.literal("false"):
+		return &jsonValue{kind: jsonBool, pos: p.position(start)}, nil
+	case p.literal("null"):
+		return &jsonValue{kind: jsonNull, pos: p.position(start)}, nil
+	default:
+		return nil, p.errorf(start, "unexpected %q", c)
+	}
+}
+
+func (p *jsonParser) literal(word string) bool {
+	if len(p.data)-p.offset >= len(word) && p.data[p.offset:p.offset+len(word)] == word {
+		p.offset += len(word)
+		return true
+	}
+
+	return false
+}
+
+func (p *jsonParser) object() (*jsonValue, error) {
+	value := &jsonValue{kind: jsonObject, pos: p.position(p.offset)}
+	p.offset++
+
+	p.skipSpace()
+	if p.offset < len(p.data) && p.data[p.offset] == '}' {
+		p.offset++
+		return value, nil
+	}
+
+	for {
+		p.skipSpace()
+		if p.offset >= len(p.data) || p.data[p.offset] != '"' {
+			return nil, p.errorf(p.offset, "expected a string key")
+		}
+
+		keyPos := p.position(p.offset)
+		key, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range value.members {
+			if m.key == key {
+				return nil, &ParseError{Pos: keyPos, Message: fmt.Sprintf("duplicate key %q", key)}
+			}
+		}
+
+		p.skipSpace()
+		if p.offset >= len(p.data) || p.data[p.offset] != ':' {
+			return nil, p.errorf(p.offset, "expected ':' after key %q", key)
+		}
+		p.offset++
+
+		p.skipSpace()
+		member, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		value.members = append(value.members, jsonMember{key: key, keyPos: keyPos, value: member})
+
+		p.skipSpace()
+		if p.offset >= len(p.data) {
+			return nil, p.errorf(p.offset, "unexpected end of input, expected ',' or '}'")
+		}
+
+		switch p.data[p.offset] {
+		case ',':
+			p.offset++
+		case '}':
+			p.offset++
+			return value, nil
+		default:
+			return nil, p.errorf(p.offset, "expected ',' or '}'")
+		}
+	}
+}
+
+func (p *jsonParser) array() (*jsonValue, error) {
+	value := &jsonValue{kind: jsonArray, pos: p.position(p.offset)}
+	p.offset++
+
+	p.skipSpace()
+	if p.offset < len(p.data) && p.data[p.offset] == ']' {
+		p.offset++
+		return value, nil
+	}
+
+	for {
+		p.skipSpace()
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		value.items = append(value.items, item)
+
+		p.skipSpace()
+		if p.offset >= len(p.data) {
+			return nil, p.errorf(p.offset, "unexpected end of input, expected ',' or ']'")
+		}
+
+		switch p.data[p.offset] {
+		case ',':
+			p.offset++
+		case ']':
+			p.offset++
+			return value, nil
+		default:
+			return nil, p.errorf(p.offset, "expected ',' or ']'")
+		}
+	}
+}
+
+//str scans a string token and lets encoding/json decode its escapes
+func (p *jsonParser) str() (string, error) {
+	start := p.offset
+	p.offset++
+	for p.offset < len(p.data) {
+		switch c := p.data[p.offset]; {
+		case c == '\\':
+			p.offset += 2
+		case c == '"':
+			p.offset++
+			var text string
+			if err := json.Unmarshal([]byte(p.data[start:p.offset]), &text); err != nil {
+				return "", p.errorf(start, "bad string: %v", err)
+			}
+			return text, nil
+		case c < 0x20:
+			return "", p.errorf(p.offset, "control character in string")
+		default:
+			p.offset++
+		}
+	}
+
+	return "", p.errorf(start, "unterminated string")
+}
+
+func (p *jsonParser) number() (*jsonValue, error) {
+	start := p.offset
+	digits := func() int {
+		n := 0
+		for p.offset < len(p.data) && p.data[p.offset] >= '0' && p.data[p.offset] <= '9' {
+			p.offset++
+			n++
+		}
+		return n
+	}
+
+	if p.data[p.offset] == '-' {
+		p.offset++
+	}
+
+	if p.offset < len(p.data) && p.data[p.offset] == '0' {
+		p.offset++
+	} else if digits() == 0 {
+		return nil, p.errorf(start, "bad number")
+	}
+
+	if p.offset < len(p.data) && p.data[p.offset] == '.' {
+		p.offset++
+		if digits() == 0 {
+			return nil, p.errorf(start, "bad number")
+		}
+	}
+
+	if p.offset < len(p.data) && (p.data[p.offset] == 'e' || p.data[p.offset] == 'E') {
+		p.offset++
+		if p.offset < len(p.data) && (p.data[p.offset] == '+' || p.data[p.offset] == '-') {
+			p.offset++
+		}
+		if digits() == 0 {
+			return nil, p.errorf(start, "bad number")
+		}
+	}
+
+	return &jsonValue{kind: jsonNumber, pos: p.position(start), text: p.data[start:p.offset]}, nil
+}
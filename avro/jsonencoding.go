@@ -0,0 +1,429 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strconv"
+)
+
+//BinaryToJSON converts the avro binary encoding of a value of schema to the avro JSON encoding, in which unions
+//other than null are wrapped in an object keyed by the branch type name, bytes and fixed are strings of the code
+//points 0 to 255 and record fields are in schema order.  Float and double NaN and infinities are the strings "NaN",
+//"Infinity" and "-Infinity".
+func BinaryToJSON(schema Schema, data []byte) ([]byte, error) {
+	c := &jsonConverter{binaryReader: binaryReader{data: data}}
+	if err := c.convert("", schema); err != nil {
+		return nil, err
+	}
+
+	if c.remaining() > 0 {
+		return nil, codecError("", "%v bytes left after the value", c.remaining())
+	}
+
+	return c.out.Bytes(), nil
+}
+
+//JSONToBinary converts the avro JSON encoding of a value of schema to the binary encoding, see BinaryToJSON.  Record
+//fields that are missing get their default.  Errors have the position in the JSON.
+func JSONToBinary(schema Schema, data []byte) ([]byte, error) {
+	v, err := parseJSON(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return appendJSON(nil, schema, v)
+}
+
+//EncodeJSON returns the avro JSON encoding of v as schema, see Marshal and BinaryToJSON
+func EncodeJSON(schema Schema, v interface{}) ([]byte, error) {
+	data, err := Marshal(schema, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return BinaryToJSON(schema, data)
+}
+
+//DecodeJSON decodes the avro JSON encoding of a value of schema into the value v points to, see Unmarshal and
+//JSONToBinary
+func DecodeJSON(schema Schema, data []byte, v interface{}) error {
+	binary, err := JSONToBinary(schema, data)
+	if err != nil {
+		return err
+	}
+
+	return Unmarshal(schema, binary, v)
+}
+
+type jsonConverter struct {
+	binaryReader
+	out bytes.Buffer
+}
+
+func (c *jsonConverter) convert(path string, schema Schema) error {
+	switch s := schema.(type) {
+	case *PrimitiveSchema:
+		return c.convertPrimitive(path, s.Primitive)
+	case *UnionSchema:
+		i, err := c.long()
+		if err != nil {
+			return readError(path, err)
+		}
+
+		if i < 0 || i >= int64(len(s.Types)) {
+			return codecError(path, "union index %v out of range for %v", i, Describe(s))
+		}
+
+		branch := s.Types[i]
+		if branch.Type() == Null {
+			c.out.WriteString("null")
+			return nil
+		}
+
+		c.out.WriteString("{" + quote(TypeName(branch)) + ":")
+		if err := c.convert(path, branch); err != nil {
+			return err
+		}
+		c.out.WriteString("}")
+	case *RecordSchema:
+		c.out.WriteString("{")
+		for i, field := range s.Fields {
+			if i > 0 {
+				c.out.WriteString(",")
+			}
+
+			c.out.WriteString(quote(field.Name) + ":")
+			if err := c.convert(JoinPath(path, field.Name), field.Type); err != nil {
+				return err
+			}
+		}
+		c.out.WriteString("}")
+	case *EnumSchema:
+		i, err := c.int()
+		if err != nil {
+			return readError(path, err)
+		}
+
+		if i < 0 || int(i) >= len(s.Symbols) {
+			return codecError(path, "enum index %v out of range for %v", i, s.FullName())
+		}
+
+		c.out.WriteString(quote(s.Symbols[i]))
+	case *FixedSchema:
+		b, err := c.fixed(s.Size)
+		if err != nil {
+			return readError(path, err)
+		}
+
+		c.out.WriteString(quoteLatin1(b))
+	case *ArraySchema:
+		return c.blocks(path, "[", "]", func() error {
+			return c.convert(JoinPath(path, "[]"), s.Items)
+		})
+	case *MapSchema:
+		return c.blocks(path, "{", "}", func() error {
+			key, err := c.string()
+			if err != nil {
+				return readError(path, err)
+			}
+
+			c.out.WriteString(quote(key) + ":")
+			return c.convert(JoinPath(path, "{}"), s.Values)
+		})
+	}
+
+	return nil
+}
+
+func (c *jsonConverter) blocks(path, open, close string, item func() error) error {
+	c.out.WriteString(open)
+	first := true
+	for {
+		count, err := c.blockCount()
+		if err != nil {
+			return readError(path, err)
+		}
+
+		if count == 0 {
+			c.out.WriteString(close)
+			return nil
+		}
+
+		for i := int64(0); i < count; i++ {
+			if !first {
+				c.out.WriteString(",")
+			}
+
+			if err := item(); err != nil {
+				return err
+			}
+			first = false
+		}
+	}
+}
+
+func (c *jsonConverter) convertPrimitive(path string, t Type) error {
+	if t == Null {
+		c.out.WriteString("null")
+		return nil
+	}
+
+	v, err := c.primitive(t)
+	if err != nil {
+		return readError(path, err)
+	}
+
+	switch v := v.(type) {
+	case bool:
+		c.out.WriteString(strconv.FormatBool(v))
+	case int32:
+		c.out.WriteString(strconv.FormatInt(int64(v), 10))
+	case int64:
+		c.out.WriteString(strconv.FormatInt(v, 10))
+	case float32:
+		c.out.WriteString(formatFloat(float64(v), 32))
+	case float64:
+		c.out.WriteString(formatFloat(v, 64))
+	case []byte:
+		c.out.WriteString(quoteLatin1(v))
+	case string:
+		c.out.WriteString(quote(v))
+	}
+
+	return nil
+}
+
+func formatFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return `"NaN"`
+	case math.IsInf(f, 1):
+		return `"Infinity"`
+	case math.IsInf(f, -1):
+		return `"-Infinity"`
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+func quoteLatin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+
+	return quote(string(runes))
+}
+
+func jsonMismatch(v *jsonValue, schema Schema) error {
+	return errorAt(v.pos, "expected %v, got %v", TypeName(schema), v.kind)
+}
+
+func appendJSON(buf []byte, schema Schema, v *jsonValue) ([]byte, error) {
+	switch s := schema.(type) {
+	case *PrimitiveSchema:
+		return appendJSONPrimitive(buf, s, v)
+	case *UnionSchema:
+		return appendJSONUnion(buf, s, v)
+	case *RecordSchema:
+		if v.kind != jsonObject {
+			return nil, jsonMismatch(v, schema)
+		}
+
+		for _, m := range v.members {
+			if _, ok := s.Field(m.key); !ok {
+				return nil, errorAt(m.keyPos, "%v has no field %v", s.FullName(), m.key)
+			}
+		}
+
+		var err error
+		for _, field := range s.Fields {
+			value, ok := v.member(field.Name)
+			if !ok {
+				if !field.HasDefault {
+					return nil, errorAt(v.pos, "missing field %v, which has no default", field.Name)
+				}
+
+				if buf, err = AppendBinary(buf, field.Type, defaultValue(field.Type, field.Default)); err != nil {
+					return nil, errorAt(v.pos, "default of %v: %v", field.Name, err)
+				}
+				continue
+			}
+
+			if buf, err = appendJSON(buf, field.Type, value); err != nil {
+				return nil, err
+			}
+		}
+
+		return buf, nil
+	case *EnumSchema:
+		if v.kind != jsonString {
+			return nil, jsonMismatch(v, schema)
+		}
+
+		for i, symbol := range s.Symbols {
+			if symbol == v.text {
+				return appendLong(buf, int64(i)), nil
+			}
+		}
+
+		return nil, errorAt(v.pos, "%q is not a symbol of %v", v.text, s.FullName())
+	case *FixedSchema:
+		b, ok := latin1(v.text)
+		if v.kind != jsonString || !ok {
+			return nil, errorAt(v.pos, "expected a string of code points up to \\u00ff for %v", s.FullName())
+		}
+
+		if len(b) != s.Size {
+			return nil, errorAt(v.pos, "%v is %v bytes, not %v", s.FullName(), s.Size, len(b))
+		}
+
+		return append(buf, b...), nil
+	case *ArraySchema:
+		if v.kind != jsonArray {
+			return nil, jsonMismatch(v, schema)
+		}
+
+		var err error
+		if len(v.items) > 0 {
+			buf = appendLong(buf, int64(len(v.items)))
+			for _, item := range v.items {
+				if buf, err = appendJSON(buf, s.Items, item); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return appendLong(buf, 0), nil
+	case *MapSchema:
+		if v.kind != jsonObject {
+			return nil, jsonMismatch(v, schema)
+		}
+
+		var err error
+		if len(v.members) > 0 {
+			buf = appendLong(buf, int64(len(v.members)))
+			for _, m := range v.members {
+				buf = appendBytes(buf, []byte(m.key))
+				if buf, err = appendJSON(buf, s.Values, m.value); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return appendLong(buf, 0), nil
+	}
+
+	return nil, errorAt(v.pos, "cannot encode %v", TypeName(schema))
+}
+
+func appendJSONUnion(buf []byte, s *UnionSchema, v *jsonValue) ([]byte, error) {
+	if v.kind == jsonNull {
+		for i, branch := range s.Types {
+			if branch.Type() == Null {
+				return appendLong(buf, int64(i)), nil
+			}
+		}
+
+		return nil, errorAt(v.pos, "union %v has no null branch", Describe(s))
+	}
+
+	if v.kind != jsonObject || len(v.members) != 1 {
+		return nil, errorAt(v.pos, "expected null or an object with one of the branches of union %v", Describe(s))
+	}
+
+	m := v.members[0]
+
+	//the full name is the spec, the unqualified name of named types is accepted when it is unambiguous
+	branchIndex := -1
+	for i, branch := range s.Types {
+		if TypeName(branch) == m.key {
+			branchIndex = i
+			break
+		}
+
+		if named, ok := branch.(NamedSchema); ok && unqualified(named.FullName()) == m.key {
+			if branchIndex >= 0 {
+				return nil, errorAt(m.keyPos, "%v is ambiguous in union %v", m.key, Describe(s))
+			}
+			branchIndex = i
+		}
+	}
+
+	if branchIndex < 0 || s.Types[branchIndex].Type() == Null {
+		return nil, errorAt(m.keyPos, "%v is not a branch of union %v", m.key, Describe(s))
+	}
+
+	return appendJSON(appendLong(buf, int64(branchIndex)), s.Types[branchIndex], m.value)
+}
+
+func appendJSONPrimitive(buf []byte, s *PrimitiveSchema, v *jsonValue) ([]byte, error) {
+	switch s.Primitive {
+	case Null:
+		if v.kind == jsonNull {
+			return buf, nil
+		}
+	case Boolean:
+		if v.kind == jsonBool {
+			return appendBoolean(buf, v.boolean), nil
+		}
+	case Int, Long:
+		if v.kind == jsonNumber {
+			bits := 64
+			if s.Primitive == Int {
+				bits = 32
+			}
+
+			n, err := strconv.ParseInt(v.text, 10, bits)
+			if err != nil {
+				return nil, errorAt(v.pos, "%v is not a valid %v", v.text, s.Primitive)
+			}
+
+			return appendLong(buf, n), nil
+		}
+	case Float, Double:
+		f, ok := jsonFloat(v)
+		if ok && s.Primitive == Float {
+			return appendFloat(buf, float32(f)), nil
+		}
+
+		if ok {
+			return appendDouble(buf, f), nil
+		}
+	case Bytes:
+		if b, ok := latin1(v.text); ok && v.kind == jsonString {
+			return appendBytes(buf, b), nil
+		}
+
+		if v.kind == jsonString {
+			return nil, errorAt(v.pos, "bytes may only contain code points up to \\u00ff")
+		}
+	case String:
+		if v.kind == jsonString {
+			return appendBytes(buf, []byte(v.text)), nil
+		}
+	}
+
+	return nil, jsonMismatch(v, s)
+}
+
+func jsonFloat(v *jsonValue) (float64, bool) {
+	switch {
+	case v.kind == jsonNumber:
+		f, err := strconv.ParseFloat(v.text, 64)
+		return f, err == nil || errors.Is(err, strconv.ErrRange)
+	case v.kind == jsonString && v.text == "NaN":
+		return math.NaN(), true
+	case v.kind == jsonString && v.text == "Infinity":
+		return math.Inf(1), true
+	case v.kind == jsonString && v.text == "-Infinity":
+		return math.Inf(-1), true
+	}
+
+	return 0, false
+}
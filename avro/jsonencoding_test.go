@@ -0,0 +1,104 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONEncoding(t *testing.T) {
+	schema := MustParse(`{"type": "record", "name": "R", "namespace": "com.mm", "fields": [
+	{"name": "i", "type": "int"},
+	{"name": "f", "type": "float"},
+	{"name": "d", "type": "double"},
+	{"name": "b", "type": "bytes"},
+	{"name": "s", "type": "string"},
+	{"name": "u", "type": ["null", "string", {"type": "enum", "name": "E", "symbols": ["A", "B"]}]},
+	{"name": "n", "type": ["null", "long"]},
+	{"name": "a", "type": {"type": "array", "items": "boolean"}},
+	{"name": "m", "type": {"type": "map", "values": {"type": "fixed", "name": "F", "size": 2}}}
+]}`)
+
+	value := map[string]interface{}{
+		"i": -3,
+		"f": float32(1.5),
+		"d": math.Inf(-1),
+		"b": []byte{0, 0xff, '"'},
+		"s": "<é>",
+		"u": "x",
+		"n": nil,
+		"a": []bool{true, false},
+		"m": map[string][]byte{"k": {1, 2}},
+	}
+
+	encoded, err := EncodeJSON(schema, value)
+	require.NoError(t, err)
+	assert.Equal(t, `{"i":-3,"f":1.5,"d":"-Infinity","b":"\u0000ÿ\"","s":"<é>","u":{"string":"x"},"n":null,"a":[true,false],"m":{"k":"\u0001\u0002"}}`, string(encoded))
+
+	binary, err := JSONToBinary(schema, encoded)
+	require.NoError(t, err)
+
+	roundTripped, err := BinaryToJSON(schema, binary)
+	require.NoError(t, err)
+	assert.Equal(t, string(encoded), string(roundTripped))
+
+	var decoded map[string]interface{}
+	require.NoError(t, DecodeJSON(schema, []byte(`{"i": 1, "f": "NaN", "d": 2e3, "b": "", "s": "", "u": {"com.mm.E": "B"}, "n": {"long": 7}, "a": [], "m": {}}`), &decoded))
+	assert.Equal(t, "B", decoded["u"])
+	assert.Equal(t, int64(7), decoded["n"])
+	assert.Equal(t, 2000.0, decoded["d"])
+	assert.True(t, math.IsNaN(float64(decoded["f"].(float32))))
+
+	//unqualified names of named branches are accepted
+	_, err = JSONToBinary(MustParse(`["null", {"type": "enum", "name": "com.mm.E", "symbols": ["A"]}]`), []byte(`{"E": "A"}`))
+	assert.NoError(t, err)
+}
+
+func TestJSONToBinaryDefaults(t *testing.T) {
+	schema := MustParse(`{"type": "record", "name": "R", "fields": [
+	{"name": "a", "type": "int"},
+	{"name": "b", "type": ["null", "string"], "default": null},
+	{"name": "c", "type": "bytes", "default": "ÿ"}
+]}`)
+
+	binary, err := JSONToBinary(schema, []byte(`{"a": 1}`))
+	require.NoError(t, err)
+
+	encoded, err := BinaryToJSON(schema, binary)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1,"b":null,"c":"ÿ"}`, string(encoded))
+}
+
+func TestJSONToBinaryErrors(t *testing.T) {
+	schema := MustParse(`{"type": "record", "name": "R", "fields": [
+	{"name": "a", "type": "int"},
+	{"name": "u", "type": ["null", "string"]}
+]}`)
+
+	errs := map[string]string{
+		`{"a": 1, "u": "x"}`:              "1:15: expected null or an object with one of the branches of union [null, string]",
+		`{"a": 1, "u": {"long": 1}}`:      "1:16: long is not a branch of union [null, string]",
+		`{"a": 4294967296, "u": null}`:    "1:7: 4294967296 is not a valid int",
+		`{"a": "1", "u": null}`:           "1:7: expected int, got string",
+		`{"u": null}`:                     "1:1: missing field a, which has no default",
+		`{"a": 1, "u": null, "extra": 1}`: "1:21: R has no field extra",
+		`{"a": 1,}`:                       "1:9: invalid JSON: expected a string key",
+	}
+
+	for data, expected := range errs {
+		_, err := JSONToBinary(schema, []byte(data))
+		assert.EqualError(t, err, expected, data)
+	}
+
+	_, err := BinaryToJSON(schema, []byte{2, 4})
+	assert.EqualError(t, err, "u: union index 2 out of range for [null, string]")
+
+	_, err = BinaryToJSON(schema, []byte{2, 0, 0})
+	assert.EqualError(t, err, ".: 1 bytes left after the value")
+}
@@ -0,0 +1,743 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//ParseError is an invalid schema and where in its text the problem is
+type ParseError struct {
+	Pos     Position
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	if !e.Pos.IsValid() {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%v: %v", e.Pos, e.Message)
+}
+
+//Names are named schemas that can be referred to by full name from a schema being parsed
+type Names map[string]NamedSchema
+
+//Parse parses the JSON text of an avro schema
+func Parse(schema string) (Schema, error) {
+	return ParseWithNames(schema, nil)
+}
+
+//ParseWithNames parses the JSON text of an avro schema that may refer to the named schemas in names, for instance
+//those defined by schema references.  Named schemas defined by the schema are added to names if it is not nil.
+func ParseWithNames(schema string, names Names) (Schema, error) {
+	value, err := parseJSON(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{names: names}
+	if p.names == nil {
+		p.names = make(Names)
+	}
+
+	return p.parse(value, "")
+}
+
+//MustParse is Parse that panics on error, it is meant for schemas that are constants in code
+func MustParse(schema string) Schema {
+	parsed, err := Parse(schema)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
+
+type parser struct {
+	names Names
+}
+
+func errorAt(pos Position, format string, args ...interface{}) error {
+	return &ParseError{Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+//ValidName returns whether name is a valid avro name, without a namespace
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+//ValidFullName returns whether name is a valid, possibly namespace qualified, avro name
+func ValidFullName(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if !ValidName(part) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *parser) parse(v *jsonValue, namespace string) (Schema, error) {
+	switch v.kind {
+	case jsonString:
+		return p.reference(v, namespace)
+	case jsonArray:
+		return p.union(v, namespace)
+	case jsonObject:
+		return p.object(v, namespace)
+	default:
+		return nil, errorAt(v.pos, "expected a type name, object or union, got %v", v.kind)
+	}
+}
+
+func (p *parser) reference(v *jsonValue, namespace string) (Schema, error) {
+	if t := Type(v.text); t.IsPrimitive() {
+		return &PrimitiveSchema{node: node{v.pos}, Primitive: t}, nil
+	}
+
+	if named, ok := p.names[fullName(v.text, namespace)]; ok {
+		return named, nil
+	}
+
+	if named, ok := p.names[v.text]; ok {
+		return named, nil
+	}
+
+	return nil, errorAt(v.pos, "unknown type %q", v.text)
+}
+
+func (p *parser) union(v *jsonValue, namespace string) (Schema, error) {
+	union := &UnionSchema{node: node{v.pos}}
+	seen := make(map[string]bool)
+
+	for _, item := range v.items {
+		branch, err := p.parse(item, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if branch.Type() == Union {
+			return nil, errorAt(item.pos, "unions may not immediately contain other unions")
+		}
+
+		name := TypeName(branch)
+		if seen[name] {
+			return nil, errorAt(item.pos, "duplicate %v in union", name)
+		}
+		seen[name] = true
+
+		union.Types = append(union.Types, branch)
+	}
+
+	return union, nil
+}
+
+//attributes tracks which members of a JSON object have been used so the rest can be kept as properties
+type attributes struct {
+	object *jsonValue
+	used   map[string]bool
+}
+
+func newAttributes(object *jsonValue, used ...string) *attributes {
+	a := &attributes{object: object, used: make(map[string]bool)}
+	for _, key := range used {
+		a.used[key] = true
+	}
+	return a
+}
+
+func (a *attributes) get(key string) (*jsonValue, bool) {
+	a.used[key] = true
+	return a.object.member(key)
+}
+
+func (a *attributes) str(key string) (string, bool, error) {
+	v, ok := a.get(key)
+	if !ok {
+		return "", false, nil
+	}
+
+	if v.kind != jsonString {
+		return "", false, errorAt(v.pos, "%q must be a string, got %v", key, v.kind)
+	}
+
+	return v.text, true, nil
+}
+
+func (a *attributes) strings(key string) ([]string, error) {
+	v, ok := a.get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	if v.kind != jsonArray {
+		return nil, errorAt(v.pos, "%q must be an array of strings, got %v", key, v.kind)
+	}
+
+	values := make([]string, 0, len(v.items))
+	for _, item := range v.items {
+		if item.kind != jsonString {
+			return nil, errorAt(item.pos, "%q must be an array of strings, got %v in it", key, item.kind)
+		}
+		values = append(values, item.text)
+	}
+
+	return values, nil
+}
+
+func (a *attributes) props() Properties {
+	var props Properties
+	for _, m := range a.object.members {
+		if a.used[m.key] {
+			continue
+		}
+
+		if props == nil {
+			props = make(Properties)
+		}
+		props[m.key] = m.value.interfaceValue()
+	}
+
+	return props
+}
+
+func (p *parser) object(v *jsonValue, namespace string) (Schema, error) {
+	typeValue, ok := v.member("type")
+	if !ok {
+		return nil, errorAt(v.pos, "missing \"type\"")
+	}
+
+	if typeValue.kind == jsonObject || typeValue.kind == jsonArray {
+		return p.parse(typeValue, namespace)
+	}
+
+	if typeValue.kind != jsonString {
+		return nil, errorAt(typeValue.pos, "\"type\" must be a string, object or array, got %v", typeValue.kind)
+	}
+
+	switch t := Type(typeValue.text); {
+	case t.IsPrimitive():
+		return p.primitive(v, t)
+	case t == Record || t == Error:
+		return p.record(v, namespace, t == Error)
+	case t == Enum:
+		return p.enum(v, namespace)
+	case t == Fixed:
+		return p.fixed(v, namespace)
+	case t == Array:
+		attrs := newAttributes(v, "type")
+		items, ok := attrs.get("items")
+		if !ok {
+			return nil, errorAt(v.pos, "array is missing \"items\"")
+		}
+
+		itemSchema, err := p.parse(items, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ArraySchema{node: node{v.pos}, Items: itemSchema, Props: attrs.props()}, nil
+	case t == Map:
+		attrs := newAttributes(v, "type")
+		values, ok := attrs.get("values")
+		if !ok {
+			return nil, errorAt(v.pos, "map is missing \"values\"")
+		}
+
+		valueSchema, err := p.parse(values, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		return &MapSchema{node: node{v.pos}, Values: valueSchema, Props: attrs.props()}, nil
+	default:
+		return p.reference(typeValue, namespace)
+	}
+}
+
+func (p *parser) primitive(v *jsonValue, t Type) (Schema, error) {
+	attrs := newAttributes(v, "type")
+	logical, err := p.logicalType(attrs, t, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PrimitiveSchema{node: node{v.pos}, Primitive: t, Logical: logical, Props: attrs.props()}, nil
+}
+
+//logicalType reads the logicalType attribute.  Logical types that are not valid for their schema are ignored as the
+//specification requires, their attributes are kept as properties.
+func (p *parser) logicalType(attrs *attributes, t Type, size int) (*LogicalType, error) {
+	v, ok := attrs.object.member("logicalType")
+	if !ok || v.kind != jsonString {
+		return nil, nil
+	}
+
+	logical := &LogicalType{Name: v.text}
+	switch logical.Name {
+	case Decimal:
+		if t != Bytes && t != Fixed {
+			return nil, nil
+		}
+
+		precision, ok := attrs.object.member("precision")
+		if !ok {
+			return nil, nil
+		}
+
+		var err error
+		if logical.Precision, err = intAttribute(precision, "precision"); err != nil || logical.Precision <= 0 {
+			return nil, nil
+		}
+
+		if scale, ok := attrs.object.member("scale"); ok {
+			if logical.Scale, err = intAttribute(scale, "scale"); err != nil || logical.Scale < 0 {
+				return nil, nil
+			}
+		}
+
+		if logical.Scale > logical.Precision || (t == Fixed && logical.Precision > maxDecimalPrecision(size)) {
+			return nil, nil
+		}
+
+		attrs.used["precision"] = true
+		attrs.used["scale"] = true
+	case UUID:
+		if t != String {
+			return nil, nil
+		}
+	case Date, TimeMillis:
+		if t != Int {
+			return nil, nil
+		}
+	case TimeMicros, TimestampMillis, TimestampMicros, LocalTimestampMillis, LocalTimestampMicros:
+		if t != Long {
+			return nil, nil
+		}
+	case Duration:
+		if t != Fixed || size != 12 {
+			return nil, nil
+		}
+	}
+
+	attrs.used["logicalType"] = true
+	return logical, nil
+}
+
+//maxDecimalPrecision is the number of base 10 digits a signed two's complement number of size bytes can always hold
+func maxDecimalPrecision(size int) int {
+	if size <= 0 {
+		return 0
+	}
+
+	max := new(big.Int).Lsh(big.NewInt(1), uint(8*size-1))
+	max.Sub(max, big.NewInt(1))
+	return len(max.String()) - 1
+}
+
+func intAttribute(v *jsonValue, key string) (int, error) {
+	if v.kind != jsonNumber {
+		return 0, errorAt(v.pos, "%q must be an integer, got %v", key, v.kind)
+	}
+
+	n, err := strconv.Atoi(v.text)
+	if err != nil {
+		return 0, errorAt(v.pos, "%q must be an integer, got %v", key, v.text)
+	}
+
+	return n, nil
+}
+
+//define reads the name, namespace and aliases of a named schema and returns its namespace
+func (p *parser) define(v *jsonValue, attrs *attributes, enclosing string) (name, namespace string, aliases []string, err error) {
+	nameValue, ok := attrs.get("name")
+	if !ok {
+		return "", "", nil, errorAt(v.pos, "missing \"name\"")
+	}
+
+	if nameValue.kind != jsonString {
+		return "", "", nil, errorAt(nameValue.pos, "\"name\" must be a string, got %v", nameValue.kind)
+	}
+
+	name = nameValue.text
+	namespace = enclosing
+	if nsValue, ok := attrs.get("namespace"); ok {
+		switch nsValue.kind {
+		case jsonString:
+			namespace = nsValue.text
+		case jsonNull:
+			namespace = ""
+		default:
+			return "", "", nil, errorAt(nsValue.pos, "\"namespace\" must be a string, got %v", nsValue.kind)
+		}
+	}
+
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		namespace = name[:dot]
+		name = name[dot+1:]
+	}
+
+	if !ValidName(name) {
+		return "", "", nil, errorAt(nameValue.pos, "invalid name %q", nameValue.text)
+	}
+
+	if namespace != "" && !ValidFullName(namespace) {
+		return "", "", nil, errorAt(v.pos, "invalid namespace %q", namespace)
+	}
+
+	if t := Type(name); t.IsPrimitive() && namespace == "" {
+		return "", "", nil, errorAt(nameValue.pos, "%q is a primitive type and cannot be redefined", name)
+	}
+
+	if _, exists := p.names[fullName(name, namespace)]; exists {
+		return "", "", nil, errorAt(nameValue.pos, "%q is already defined", fullName(name, namespace))
+	}
+
+	aliases, err = attrs.strings("aliases")
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	for _, alias := range aliases {
+		if !ValidFullName(alias) {
+			aliasValue, _ := attrs.object.member("aliases")
+			return "", "", nil, errorAt(aliasValue.pos, "invalid alias %q", alias)
+		}
+	}
+
+	return name, namespace, aliases, nil
+}
+
+func (p *parser) record(v *jsonValue, enclosing string, isError bool) (Schema, error) {
+	attrs := newAttributes(v, "type")
+	name, namespace, aliases, err := p.define(v, attrs, enclosing)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, _, err := attrs.str("doc")
+	if err != nil {
+		return nil, err
+	}
+
+	record := &RecordSchema{node: node{v.pos}, Name: name, Namespace: namespace, Aliases: aliases, Doc: doc, IsError: isError}
+	p.names[record.FullName()] = record
+
+	fields, ok := attrs.get("fields")
+	if !ok {
+		return nil, errorAt(v.pos, "record %q is missing \"fields\"", record.FullName())
+	}
+
+	if fields.kind != jsonArray {
+		return nil, errorAt(fields.pos, "\"fields\" must be an array, got %v", fields.kind)
+	}
+
+	for _, fieldValue := range fields.items {
+		field, err := p.field(fieldValue, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := record.Field(field.Name); exists {
+			return nil, errorAt(fieldValue.pos, "duplicate field %q in record %q", field.Name, record.FullName())
+		}
+
+		record.Fields = append(record.Fields, field)
+	}
+
+	record.Props = attrs.props()
+	return record, nil
+}
+
+func (p *parser) field(v *jsonValue, namespace string) (*Field, error) {
+	if v.kind != jsonObject {
+		return nil, errorAt(v.pos, "a field must be an object, got %v", v.kind)
+	}
+
+	attrs := newAttributes(v)
+	name, ok, err := attrs.str("name")
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errorAt(v.pos, "field is missing \"name\"")
+	}
+
+	if !ValidName(name) {
+		nameValue, _ := v.member("name")
+		return nil, errorAt(nameValue.pos, "invalid field name %q", name)
+	}
+
+	typeValue, ok := attrs.get("type")
+	if !ok {
+		return nil, errorAt(v.pos, "field %q is missing \"type\"", name)
+	}
+
+	fieldType, err := p.parse(typeValue, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	field := &Field{node: node{v.pos}, Name: name, Type: fieldType, Order: Ascending}
+
+	if field.Doc, _, err = attrs.str("doc"); err != nil {
+		return nil, err
+	}
+
+	if field.Aliases, err = attrs.strings("aliases"); err != nil {
+		return nil, err
+	}
+
+	if order, ok, err := attrs.str("order"); err != nil {
+		return nil, err
+	} else if ok {
+		field.Order = Order(order)
+		if field.Order != Ascending && field.Order != Descending && field.Order != Ignore {
+			orderValue, _ := v.member("order")
+			return nil, errorAt(orderValue.pos, "field %q has invalid order %q", name, order)
+		}
+	}
+
+	if defaultValue, ok := attrs.get("default"); ok {
+		if err := checkDefault(fieldType, defaultValue); err != nil {
+			return nil, errorAt(defaultValue.pos, "invalid default for field %q: %v", name, err)
+		}
+
+		field.Default = defaultValue.interfaceValue()
+		field.HasDefault = true
+	}
+
+	field.Props = attrs.props()
+	return field, nil
+}
+
+func (p *parser) enum(v *jsonValue, enclosing string) (Schema, error) {
+	attrs := newAttributes(v, "type")
+	name, namespace, aliases, err := p.define(v, attrs, enclosing)
+	if err != nil {
+		return nil, err
+	}
+
+	enum := &EnumSchema{node: node{v.pos}, Name: name, Namespace: namespace, Aliases: aliases}
+	if enum.Doc, _, err = attrs.str("doc"); err != nil {
+		return nil, err
+	}
+
+	symbolsValue, ok := attrs.get("symbols")
+	if !ok {
+		return nil, errorAt(v.pos, "enum %q is missing \"symbols\"", enum.FullName())
+	}
+
+	if symbolsValue.kind != jsonArray {
+		return nil, errorAt(symbolsValue.pos, "\"symbols\" must be an array, got %v", symbolsValue.kind)
+	}
+
+	for _, symbol := range symbolsValue.items {
+		if symbol.kind != jsonString || !ValidName(symbol.text) {
+			return nil, errorAt(symbol.pos, "invalid symbol in enum %q", enum.FullName())
+		}
+
+		if _, exists := enum.Symbol(symbol.text); exists {
+			return nil, errorAt(symbol.pos, "duplicate symbol %q in enum %q", symbol.text, enum.FullName())
+		}
+
+		enum.Symbols = append(enum.Symbols, symbol.text)
+	}
+
+	if def, ok, err := attrs.str("default"); err != nil {
+		return nil, err
+	} else if ok {
+		if _, exists := enum.Symbol(def); !exists {
+			defaultValue, _ := v.member("default")
+			return nil, errorAt(defaultValue.pos, "default %q is not a symbol of enum %q", def, enum.FullName())
+		}
+		enum.Default = def
+		enum.HasDefault = true
+	}
+
+	enum.Props = attrs.props()
+	p.names[enum.FullName()] = enum
+	return enum, nil
+}
+
+func (p *parser) fixed(v *jsonValue, enclosing string) (Schema, error) {
+	attrs := newAttributes(v, "type")
+	name, namespace, aliases, err := p.define(v, attrs, enclosing)
+	if err != nil {
+		return nil, err
+	}
+
+	fixed := &FixedSchema{node: node{v.pos}, Name: name, Namespace: namespace, Aliases: aliases}
+	if fixed.Doc, _, err = attrs.str("doc"); err != nil {
+		return nil, err
+	}
+
+	sizeValue, ok := attrs.get("size")
+	if !ok {
+		return nil, errorAt(v.pos, "fixed %q is missing \"size\"", fixed.FullName())
+	}
+
+	if fixed.Size, err = intAttribute(sizeValue, "size"); err != nil {
+		return nil, err
+	}
+
+	if fixed.Size < 0 {
+		return nil, errorAt(sizeValue.pos, "fixed %q has a negative size", fixed.FullName())
+	}
+
+	if fixed.Logical, err = p.logicalType(attrs, Fixed, fixed.Size); err != nil {
+		return nil, err
+	}
+
+	fixed.Props = attrs.props()
+	p.names[fixed.FullName()] = fixed
+	return fixed, nil
+}
+
+//checkDefault returns why v cannot be the default of a field of schema s
+func checkDefault(s Schema, v *jsonValue) error {
+	mismatch := func() error {
+		return fmt.Errorf("expected %v, got %v", TypeName(s), v.kind)
+	}
+
+	switch s := s.(type) {
+	case *PrimitiveSchema:
+		switch s.Primitive {
+		case Null:
+			if v.kind != jsonNull {
+				return mismatch()
+			}
+		case Boolean:
+			if v.kind != jsonBool {
+				return mismatch()
+			}
+		case Int, Long:
+			if v.kind != jsonNumber {
+				return mismatch()
+			}
+
+			bits := 64
+			if s.Primitive == Int {
+				bits = 32
+			}
+
+			if _, err := strconv.ParseInt(v.text, 10, bits); err != nil {
+				return fmt.Errorf("%v is not a valid %v", v.text, s.Primitive)
+			}
+		case Float, Double:
+			if v.kind != jsonNumber {
+				return mismatch()
+			}
+
+			if f, err := strconv.ParseFloat(v.text, 64); err != nil || (s.Primitive == Float && math.Abs(f) > math.MaxFloat32) {
+				return fmt.Errorf("%v is not a valid %v", v.text, s.Primitive)
+			}
+		case Bytes:
+			if v.kind != jsonString {
+				return mismatch()
+			}
+
+			if _, ok := latin1(v.text); !ok {
+				return fmt.Errorf("bytes defaults may only contain characters up to \\u00ff")
+			}
+		case String:
+			if v.kind != jsonString {
+				return mismatch()
+			}
+		}
+	case *FixedSchema:
+		if v.kind != jsonString {
+			return mismatch()
+		}
+
+		b, ok := latin1(v.text)
+		if !ok {
+			return fmt.Errorf("fixed defaults may only contain characters up to \\u00ff")
+		}
+
+		if len(b) != s.Size {
+			return fmt.Errorf("expected %v bytes for %v, got %v", s.Size, s.FullName(), len(b))
+		}
+	case *EnumSchema:
+		if v.kind != jsonString {
+			return mismatch()
+		}
+
+		if _, ok := s.Symbol(v.text); !ok {
+			return fmt.Errorf("%q is not a symbol of %v", v.text, s.FullName())
+		}
+	case *ArraySchema:
+		if v.kind != jsonArray {
+			return mismatch()
+		}
+
+		for i, item := range v.items {
+			if err := checkDefault(s.Items, item); err != nil {
+				return fmt.Errorf("item %v: %v", i, err)
+			}
+		}
+	case *MapSchema:
+		if v.kind != jsonObject {
+			return mismatch()
+		}
+
+		for _, m := range v.members {
+			if err := checkDefault(s.Values, m.value); err != nil {
+				return fmt.Errorf("key %q: %v", m.key, err)
+			}
+		}
+	case *UnionSchema:
+		//the default of a union is a value of its first type
+		if len(s.Types) == 0 {
+			return fmt.Errorf("an empty union has no default")
+		}
+
+		if err := checkDefault(s.Types[0], v); err != nil {
+			return fmt.Errorf("the default of a union must be its first type: %v", err)
+		}
+	case *RecordSchema:
+		if v.kind != jsonObject {
+			return mismatch()
+		}
+
+		for _, field := range s.Fields {
+			value, ok := v.member(field.Name)
+			if !ok {
+				if field.HasDefault {
+					continue
+				}
+				return fmt.Errorf("missing field %q of %v", field.Name, s.FullName())
+			}
+
+			if err := checkDefault(field.Type, value); err != nil {
+				return fmt.Errorf("field %q: %v", field.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//latin1 converts a string holding bytes as code points, the way avro writes bytes and fixed defaults, to bytes
+func latin1(s string) ([]byte, bool) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, false
+		}
+		b = append(b, byte(r))
+	}
+
+	return b, true
+}
@@ -0,0 +1,180 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrimitives(t *testing.T) {
+	for _, primitive := range []Type{Null, Boolean, Int, Long, Float, Double, Bytes, String} {
+		parsed, err := Parse(`"` + string(primitive) + `"`)
+		require.NoError(t, err, string(primitive))
+		assert.Equal(t, primitive, parsed.Type())
+
+		parsed, err = Parse(`{"type": "` + string(primitive) + `"}`)
+		require.NoError(t, err, string(primitive))
+		assert.Equal(t, primitive, parsed.Type())
+	}
+}
+
+func TestParseRecord(t *testing.T) {
+	parsed, err := Parse(`{
+	"type": "record",
+	"name": "Event",
+	"namespace": "com.mediamath.sr",
+	"doc": "an event",
+	"aliases": ["OldEvent"],
+	"owner": "data",
+	"fields": [
+		{"name": "id", "type": "long", "doc": "the id"},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"], "default": "A"}, "default": "B"},
+		{"name": "hash", "type": {"type": "fixed", "name": "other.Hash", "size": 16}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}, "default": []},
+		{"name": "counts", "type": {"type": "map", "values": "int"}, "default": {"a": 1}},
+		{"name": "note", "type": ["null", "string"], "default": null, "order": "ignore"},
+		{"name": "again", "type": "Kind"},
+		{"name": "hash2", "type": "other.Hash"},
+		{"name": "next", "type": ["null", "Event"], "default": null}
+	]
+}`)
+	require.NoError(t, err)
+
+	record, ok := parsed.(*RecordSchema)
+	require.True(t, ok)
+	assert.Equal(t, "com.mediamath.sr.Event", record.FullName())
+	assert.Equal(t, []string{"com.mediamath.sr.OldEvent"}, record.FullAliases())
+	assert.Equal(t, "an event", record.Doc)
+	assert.Equal(t, Properties{"owner": "data"}, record.Props)
+	require.Len(t, record.Fields, 9)
+
+	id := record.Fields[0]
+	assert.Equal(t, "id", id.Name)
+	assert.Equal(t, Long, id.Type.Type())
+	assert.Equal(t, "the id", id.Doc)
+	assert.False(t, id.HasDefault)
+	assert.Equal(t, Position{Offset: 152, Line: 9, Column: 3}, id.Position())
+
+	kind := record.Fields[1].Type.(*EnumSchema)
+	assert.Equal(t, "com.mediamath.sr.Kind", kind.FullName())
+	assert.Equal(t, []string{"A", "B"}, kind.Symbols)
+	assert.Equal(t, "A", kind.Default)
+	assert.Equal(t, "B", record.Fields[1].Default)
+
+	hash := record.Fields[2].Type.(*FixedSchema)
+	assert.Equal(t, "other.Hash", hash.FullName())
+	assert.Equal(t, 16, hash.Size)
+
+	assert.Equal(t, String, record.Fields[3].Type.(*ArraySchema).Items.Type())
+	assert.Equal(t, []interface{}{}, record.Fields[3].Default)
+	assert.Equal(t, Int, record.Fields[4].Type.(*MapSchema).Values.Type())
+	assert.Equal(t, map[string]interface{}{"a": json.Number("1")}, record.Fields[4].Default)
+
+	note := record.Fields[5]
+	assert.True(t, note.HasDefault)
+	assert.Nil(t, note.Default)
+	assert.Equal(t, Ignore, note.Order)
+	assert.True(t, note.Type.(*UnionSchema).Nullable())
+
+	assert.True(t, record.Fields[6].Type == kind, "references resolve to the defining schema")
+	assert.True(t, record.Fields[7].Type == hash)
+	assert.True(t, record.Fields[8].Type.(*UnionSchema).Types[1] == record, "records may refer to themselves")
+}
+
+func TestParseNamespaces(t *testing.T) {
+	parsed, err := Parse(`{
+	"type": "record", "name": "a.b.Outer",
+	"fields": [
+		{"name": "inner", "type": {"type": "record", "name": "Inner", "fields": []}},
+		{"name": "other", "type": {"type": "record", "name": "Other", "namespace": "x", "fields": [
+			{"name": "e", "type": {"type": "enum", "name": "E", "symbols": ["S"]}}
+		]}},
+		{"name": "global", "type": {"type": "record", "name": "Global", "namespace": "", "fields": []}}
+	]
+}`)
+	require.NoError(t, err)
+
+	record := parsed.(*RecordSchema)
+	assert.Equal(t, "a.b", record.Namespace)
+	assert.Equal(t, "Outer", record.Name)
+	assert.Equal(t, "a.b.Inner", record.Fields[0].Type.(NamedSchema).FullName())
+	other := record.Fields[1].Type.(*RecordSchema)
+	assert.Equal(t, "x.Other", other.FullName())
+	assert.Equal(t, "x.E", other.Fields[0].Type.(NamedSchema).FullName())
+	assert.Equal(t, "Global", record.Fields[2].Type.(NamedSchema).FullName())
+}
+
+func TestParseLogicalTypes(t *testing.T) {
+	test := func(schema string, expected *LogicalType) {
+		parsed, err := Parse(schema)
+		require.NoError(t, err, schema)
+
+		switch s := parsed.(type) {
+		case *PrimitiveSchema:
+			assert.Equal(t, expected, s.Logical, schema)
+		case *FixedSchema:
+			assert.Equal(t, expected, s.Logical, schema)
+		default:
+			t.Fatalf("unexpected %T", parsed)
+		}
+	}
+
+	test(`{"type": "int", "logicalType": "date"}`, &LogicalType{Name: Date})
+	test(`{"type": "long", "logicalType": "timestamp-millis"}`, &LogicalType{Name: TimestampMillis})
+	test(`{"type": "string", "logicalType": "uuid"}`, &LogicalType{Name: UUID})
+	test(`{"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}`, &LogicalType{Name: Decimal, Precision: 9, Scale: 2})
+	test(`{"type": "fixed", "name": "D", "size": 4, "logicalType": "decimal", "precision": 9}`, &LogicalType{Name: Decimal, Precision: 9})
+	test(`{"type": "fixed", "name": "Dur", "size": 12, "logicalType": "duration"}`, &LogicalType{Name: Duration})
+
+	//invalid logical types are ignored
+	test(`{"type": "string", "logicalType": "date"}`, nil)
+	test(`{"type": "bytes", "logicalType": "decimal", "precision": 2, "scale": 3}`, nil)
+	test(`{"type": "fixed", "name": "D", "size": 4, "logicalType": "decimal", "precision": 10}`, nil)
+}
+
+func TestParseErrors(t *testing.T) {
+	test := func(schema string, line, column int, message string) {
+		_, err := Parse(schema)
+		require.Error(t, err, schema)
+
+		parseError, ok := err.(*ParseError)
+		require.True(t, ok, "%T", err)
+		assert.Equal(t, line, parseError.Pos.Line, schema)
+		assert.Equal(t, column, parseError.Pos.Column, schema)
+		assert.Contains(t, parseError.Message, message, schema)
+	}
+
+	test(`"nope"`, 1, 1, `unknown type "nope"`)
+	test(`{"type": "record", "name": "R", "fields": [`, 1, 44, "invalid JSON")
+	test(`{"type": "record", "name": "R"}`, 1, 1, `missing "fields"`)
+	test(`{"type": "record", "name": "1R", "fields": []}`, 1, 28, `invalid name "1R"`)
+	test("{\"type\": \"record\", \"name\": \"R\", \"fields\": [\n  {\"name\": \"a\", \"type\": \"int\"},\n  {\"name\": \"a\", \"type\": \"int\"}\n]}", 3, 3, `duplicate field "a"`)
+	test("{\"type\": \"record\", \"name\": \"R\", \"fields\": [\n  {\"name\": \"a\", \"type\": \"int\", \"default\": \"x\"}\n]}", 2, 43, `invalid default for field "a": expected int, got string`)
+	test(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["null", "int"], "default": 1.5}]}`, 1, 94, "must be its first type")
+	test(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["null", "string"], "default": "x"}]}`, 1, 97, "must be its first type: expected null, got string")
+	test(`{"type": "enum", "name": "E", "symbols": ["A", "A"]}`, 1, 48, `duplicate symbol "A"`)
+	test(`{"type": "enum", "name": "E", "symbols": ["A"], "default": "B"}`, 1, 60, `"B" is not a symbol`)
+	test(`{"type": "fixed", "name": "F", "size": -1}`, 1, 40, "negative size")
+	test(`["int", "int"]`, 1, 9, "duplicate int in union")
+	test(`["int", ["string"]]`, 1, 9, "unions may not immediately contain other unions")
+	test(`{"type": "array"}`, 1, 1, `missing "items"`)
+	test(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": {"type": "record", "name": "R", "fields": []}}]}`, 1, 93, `"R" is already defined`)
+	test(`{"type": "record", "name": "int", "fields": []}`, 1, 28, "primitive type")
+	test(`{"type": "map", "values": "long", "values": "int"}`, 1, 35, `duplicate key "values"`)
+}
+
+func TestParseWithNames(t *testing.T) {
+	names := make(Names)
+	_, err := ParseWithNames(`{"type": "enum", "name": "com.mm.Color", "symbols": ["RED"]}`, names)
+	require.NoError(t, err)
+
+	parsed, err := ParseWithNames(`{"type": "record", "name": "Car", "namespace": "com.mm", "fields": [{"name": "color", "type": "Color"}]}`, names)
+	require.NoError(t, err)
+	assert.Equal(t, Enum, parsed.(*RecordSchema).Fields[0].Type.Type())
+}
@@ -0,0 +1,371 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//Symbols is implemented by string types that are avro enums.  GenerateGo generates it for enums.
+type Symbols interface {
+	AvroSymbols() []string
+}
+
+var (
+	symbolsType = reflect.TypeOf((*Symbols)(nil)).Elem()
+	ratPtrType  = reflect.PtrTo(ratType)
+)
+
+//SchemaOf returns the schema of the Go type of v that Marshal writes values of v as.
+//
+//Structs are records named after their type, bool is boolean, int8 to int32 and uint8 and uint16 are int, other
+//integers are long, float32 is float, float64 is double, string is string, []byte is bytes and byte arrays are fixed.
+//Other slices and arrays are arrays and maps with string keys are maps.  String types implementing Symbols are enums.
+//Pointers are unions of null and the type they point to, except *big.Rat which like big.Rat is a decimal.  Structs
+//embedding TaggedUnion are unions of null and their fields.  time.Time is a timestamp-millis and time.Duration is a
+//time-micros.
+//
+//Struct fields are described by their `avro` tag: the field name, then a comma separated list of options.
+//namespace=com.mm sets the namespace of the record, enum or fixed of the field, default=JSON sets its default, which
+//may be a string without quotes, precision=9 and scale=2 make a big.Rat field a decimal and doc= documents the field
+//with the rest of the tag, commas included.  Pointer fields default to null.  A field named _ sets the name, namespace
+//and doc of the record itself.
+//
+//	type Event struct {
+//		_    struct{}  `avro:"Event,namespace=com.mm,doc=Something that happened"`
+//		ID   string    `avro:"id,doc=unique, and never reused"`
+//		At   time.Time `avro:"at"`
+//		Kind string    `avro:"kind,default=click"`
+//		Cost *big.Rat  `avro:"cost,precision=9,scale=2"`
+//		Note *string   `avro:"note"`
+//	}
+func SchemaOf(v interface{}) (Schema, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("avro: no schema for nil")
+	}
+
+	r := &reflector{named: make(map[reflect.Type]Schema), types: make(map[string]reflect.Type)}
+	s, err := r.schema(t, t.Name(), "", goTag{})
+	if err != nil {
+		return nil, err
+	}
+
+	//parsing the schema checks its names and defaults as if it had been written by hand
+	return Parse(Format(s))
+}
+
+//goTag is the avro tag of a struct field
+type goTag struct {
+	name       string
+	namespace  string
+	doc        string
+	defaultSet bool
+	defaults   string
+	precision  int
+	scale      int
+}
+
+func parseTag(tag string) (goTag, error) {
+	parts := strings.SplitN(tag, ",", 2)
+	parsed := goTag{name: parts[0]}
+	if len(parts) == 1 {
+		return parsed, nil
+	}
+
+	rest := parts[1]
+	for rest != "" {
+		equals := strings.Index(rest, "=")
+		if equals < 0 {
+			return parsed, fmt.Errorf("option %q of tag %q is not key=value", rest, tag)
+		}
+
+		key, value := rest[:equals], rest[equals+1:]
+		if key == "doc" {
+			parsed.doc = value
+			break
+		}
+
+		if key == "default" {
+			parsed.defaultSet = true
+			parsed.defaults, rest = jsonPrefix(value)
+			continue
+		}
+
+		option := strings.SplitN(value, ",", 2)
+		value, rest = option[0], ""
+		if len(option) > 1 {
+			rest = option[1]
+		}
+
+		var err error
+		switch key {
+		case "namespace":
+			parsed.namespace = value
+		case "precision":
+			parsed.precision, err = strconv.Atoi(value)
+		case "scale":
+			parsed.scale, err = strconv.Atoi(value)
+		default:
+			return parsed, fmt.Errorf("unknown option %q in tag %q", key, tag)
+		}
+
+		if err != nil {
+			return parsed, fmt.Errorf("%v of tag %q: %v", key, tag, err)
+		}
+	}
+
+	return parsed, nil
+}
+
+//jsonPrefix splits the JSON value that s starts with from the options after it.  If s does not start with JSON the
+//value is the string up to the next comma.
+func jsonPrefix(s string) (string, string) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+
+	var value json.RawMessage
+	if err := decoder.Decode(&value); err == nil {
+		rest := s[decoder.InputOffset():]
+		if rest == "" {
+			return string(value), ""
+		}
+
+		if strings.HasPrefix(rest, ",") {
+			return string(value), rest[1:]
+		}
+	}
+
+	parts := strings.SplitN(s, ",", 2)
+	if len(parts) == 1 {
+		return quote(parts[0]), ""
+	}
+
+	return quote(parts[0]), parts[1]
+}
+
+type reflector struct {
+	//named are the records, enums and fixed already derived, which are referred to rather than defined again
+	named map[reflect.Type]Schema
+	//types are the Go types of the named schemas by full name
+	types map[string]reflect.Type
+}
+
+//define reserves the full name of a named schema for a Go type
+func (r *reflector) define(t reflect.Type, name, namespace string) error {
+	if !ValidName(name) {
+		return fmt.Errorf("avro: %v has no valid avro name, %q", t, name)
+	}
+
+	full := fullName(name, namespace)
+	if other, ok := r.types[full]; ok && other != t {
+		return fmt.Errorf("avro: %v and %v are both named %v", other, t, full)
+	}
+
+	r.types[full] = t
+	return nil
+}
+
+//schema derives the schema of t.  name is the name of t if it is a named schema without a Go name, namespace is that
+//of the enclosing record and tag is the tag of the field of type t.
+func (r *reflector) schema(t reflect.Type, name, namespace string, tag goTag) (Schema, error) {
+	if tag.namespace != "" {
+		namespace = tag.namespace
+	}
+
+	if t.Name() != "" {
+		name = t.Name()
+	}
+
+	if s, ok := r.named[t]; ok {
+		return s, nil
+	}
+
+	switch {
+	case t == timeType:
+		return &PrimitiveSchema{Primitive: Long, Logical: &LogicalType{Name: TimestampMillis}}, nil
+	case t == durationType:
+		return &PrimitiveSchema{Primitive: Long, Logical: &LogicalType{Name: TimeMicros}}, nil
+	case t == ratType || t == ratPtrType:
+		if tag.precision <= 0 {
+			return nil, fmt.Errorf("avro: %v needs a precision in its tag", t)
+		}
+		return &PrimitiveSchema{Primitive: Bytes, Logical: &LogicalType{Name: Decimal, Precision: tag.precision, Scale: tag.scale}}, nil
+	case t.Kind() == reflect.String && t.Implements(symbolsType):
+		if err := r.define(t, name, namespace); err != nil {
+			return nil, err
+		}
+
+		symbols := reflect.Zero(t).Interface().(Symbols).AvroSymbols()
+		enum := &EnumSchema{Name: name, Namespace: namespace, Symbols: symbols}
+		r.named[t] = enum
+		return enum, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &PrimitiveSchema{Primitive: Boolean}, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &PrimitiveSchema{Primitive: Int}, nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &PrimitiveSchema{Primitive: Long}, nil
+	case reflect.Float32:
+		return &PrimitiveSchema{Primitive: Float}, nil
+	case reflect.Float64:
+		return &PrimitiveSchema{Primitive: Double}, nil
+	case reflect.String:
+		return &PrimitiveSchema{Primitive: String}, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &PrimitiveSchema{Primitive: Bytes}, nil
+		}
+
+		items, err := r.schema(t.Elem(), name+"Item", namespace, goTag{precision: tag.precision, scale: tag.scale})
+		return &ArraySchema{Items: items}, err
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if err := r.define(t, name, namespace); err != nil {
+				return nil, err
+			}
+
+			fixed := &FixedSchema{Name: name, Namespace: namespace, Size: t.Len()}
+			r.named[t] = fixed
+			return fixed, nil
+		}
+
+		items, err := r.schema(t.Elem(), name+"Item", namespace, goTag{precision: tag.precision, scale: tag.scale})
+		return &ArraySchema{Items: items}, err
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("avro: %v does not have string keys", t)
+		}
+
+		values, err := r.schema(t.Elem(), name+"Value", namespace, goTag{precision: tag.precision, scale: tag.scale})
+		return &MapSchema{Values: values}, err
+	case reflect.Ptr:
+		s, err := r.schema(t.Elem(), name, namespace, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if union, ok := s.(*UnionSchema); ok && union.Nullable() {
+			return s, nil
+		}
+
+		//a union's default is a value of its first branch
+		if tag.defaultSet && tag.defaults != "null" {
+			return &UnionSchema{Types: []Schema{s, &PrimitiveSchema{Primitive: Null}}}, nil
+		}
+
+		return &UnionSchema{Types: []Schema{&PrimitiveSchema{Primitive: Null}, s}}, nil
+	case reflect.Struct:
+		if isTaggedUnion(t) {
+			return r.taggedUnion(t, name, namespace, tag)
+		}
+
+		return r.record(t, name, namespace)
+	}
+
+	return nil, fmt.Errorf("avro: no schema for %v", t)
+}
+
+func (r *reflector) taggedUnion(t reflect.Type, name, namespace string, tag goTag) (Schema, error) {
+	union := &UnionSchema{Types: []Schema{&PrimitiveSchema{Primitive: Null}}}
+	for i := 1; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() != reflect.Ptr {
+			return nil, fmt.Errorf("avro: field %v of tagged union %v is not a pointer", field.Name, t)
+		}
+
+		branchType := field.Type.Elem()
+		if field.Type == ratPtrType {
+			branchType = field.Type
+		}
+
+		branch, err := r.schema(branchType, name+field.Name, namespace, goTag{precision: tag.precision, scale: tag.scale})
+		if err != nil {
+			return nil, err
+		}
+
+		if TypeName(branch) != branchName(field) {
+			return nil, fmt.Errorf("avro: field %v of tagged union %v is tagged %v but is a %v", field.Name, t, branchName(field), TypeName(branch))
+		}
+
+		union.Types = append(union.Types, branch)
+	}
+
+	return union, nil
+}
+
+func (r *reflector) record(t reflect.Type, name, namespace string) (Schema, error) {
+	record := &RecordSchema{}
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Name == "_" {
+			self, err := parseTag(field.Tag.Get("avro"))
+			if err != nil {
+				return nil, fmt.Errorf("avro: %v: %v", t, err)
+			}
+
+			if self.name != "" {
+				name = self.name
+			}
+			if self.namespace != "" {
+				namespace = self.namespace
+			}
+			if self.doc != "" {
+				record.Doc = self.doc
+			}
+		}
+	}
+
+	if err := r.define(t, name, namespace); err != nil {
+		return nil, err
+	}
+
+	//the record is known before its fields so recursive types refer to it
+	record.Name, record.Namespace = name, namespace
+	r.named[t] = record
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Tag.Get("avro") == "-" {
+			continue
+		}
+
+		fieldTag, err := parseTag(field.Tag.Get("avro"))
+		if err != nil {
+			return nil, fmt.Errorf("avro: %v.%v: %v", t, field.Name, err)
+		}
+
+		if fieldTag.name == "" {
+			fieldTag.name = field.Name
+		}
+
+		fieldSchema, err := r.schema(field.Type, name+field.Name, namespace, fieldTag)
+		if err != nil {
+			return nil, err
+		}
+
+		f := &Field{Name: fieldTag.name, Type: fieldSchema, Doc: fieldTag.doc, Order: Ascending}
+		if fieldTag.defaultSet {
+			decoder := json.NewDecoder(bytes.NewReader([]byte(fieldTag.defaults)))
+			decoder.UseNumber()
+			if err := decoder.Decode(&f.Default); err != nil {
+				return nil, fmt.Errorf("avro: default of %v.%v: %v", t, field.Name, err)
+			}
+			f.HasDefault = true
+		} else if union, ok := fieldSchema.(*UnionSchema); ok && union.Types[0].Type() == Null {
+			f.HasDefault = true
+		}
+
+		record.Fields = append(record.Fields, f)
+	}
+
+	return record, nil
+}
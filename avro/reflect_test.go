@@ -0,0 +1,130 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reflectedKind string
+
+func (reflectedKind) AvroSymbols() []string {
+	return []string{"CLICK", "VIEW"}
+}
+
+type reflectedHash [4]byte
+
+type reflectedEvent struct {
+	_        struct{}          `avro:"Event,namespace=com.mm,doc=Something that happened"`
+	ID       string            `avro:"id,doc=unique, and never reused"`
+	At       time.Time         `avro:"at"`
+	Kind     reflectedKind     `avro:"kind,default=VIEW"`
+	Cost     *big.Rat          `avro:"cost,precision=9,scale=2"`
+	Count    int32             `avro:"count,default=1"`
+	Note     *string           `avro:"note"`
+	Hash     reflectedHash     `avro:"hash"`
+	Labels   map[string]string `avro:"labels,default={}"`
+	Payload  nameOrID          `avro:"payload"`
+	Children []reflectedEvent  `avro:"children,default=[]"`
+	Parent   *reflectedEvent   `avro:"parent"`
+	Ignored  string            `avro:"-"`
+	Small    uint16
+	internal int
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema, err := SchemaOf(reflectedEvent{})
+	require.NoError(t, err)
+
+	expected := MustParse(`{"type": "record", "name": "Event", "namespace": "com.mm", "doc": "Something that happened", "fields": [
+		{"name": "id", "type": "string", "doc": "unique, and never reused"},
+		{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "kind", "type": {"type": "enum", "name": "reflectedKind", "symbols": ["CLICK", "VIEW"]}, "default": "VIEW"},
+		{"name": "cost", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}},
+		{"name": "count", "type": "int", "default": 1},
+		{"name": "note", "type": ["null", "string"], "default": null},
+		{"name": "hash", "type": {"type": "fixed", "name": "reflectedHash", "size": 4}},
+		{"name": "labels", "type": {"type": "map", "values": "string"}, "default": {}},
+		{"name": "payload", "type": ["null", "string", "long", "int"], "default": null},
+		{"name": "children", "type": {"type": "array", "items": "Event"}, "default": []},
+		{"name": "parent", "type": ["null", "Event"], "default": null},
+		{"name": "Small", "type": "int"}
+	]}`)
+	assert.Equal(t, Format(expected), Format(schema))
+
+	note := "hello"
+	id := int64(5)
+	event := reflectedEvent{
+		ID:       "e1",
+		At:       time.Unix(1, 0).UTC(),
+		Kind:     "CLICK",
+		Cost:     big.NewRat(5, 4),
+		Note:     &note,
+		Hash:     reflectedHash{1, 2, 3, 4},
+		Labels:   map[string]string{"a": "b"},
+		Payload:  nameOrID{Long: &id},
+		Children: []reflectedEvent{{ID: "e2", At: time.Unix(2, 0).UTC(), Kind: "VIEW", Cost: big.NewRat(0, 1), Labels: map[string]string{}, Children: []reflectedEvent{}}},
+	}
+
+	data, err := Marshal(schema, event)
+	require.NoError(t, err)
+
+	var decoded reflectedEvent
+	require.NoError(t, Unmarshal(schema, data, &decoded))
+	assert.Equal(t, event, decoded)
+}
+
+func TestSchemaOfTypes(t *testing.T) {
+	types := map[string]interface{}{
+		`"long"`:                            int64(0),
+		`["null", "double"]`:                new(float64),
+		`{"type": "array", "items": "int"}`: []int8{},
+		`"bytes"`:                           []byte{},
+		`{"type": "long", "logicalType": "time-micros"}`: time.Second,
+	}
+
+	for expected, v := range types {
+		schema, err := SchemaOf(v)
+		require.NoError(t, err, expected)
+		assert.Equal(t, Format(MustParse(expected)), Format(schema), expected)
+	}
+
+	type withDefault struct {
+		Name *string `avro:"name,default=ann"`
+	}
+
+	schema, err := SchemaOf(withDefault{})
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"record","name":"withDefault","fields":[{"name":"name","type":["string","null"],"default":"ann"}]}`, Format(schema))
+}
+
+func TestSchemaOfErrors(t *testing.T) {
+	type badDefault struct {
+		Count int `avro:"count,default=many"`
+	}
+
+	type noPrecision struct {
+		Cost big.Rat
+	}
+
+	type badOption struct {
+		Name string `avro:"name,size=3"`
+	}
+
+	type wrongBranch struct {
+		TaggedUnion
+		Name *string `avro:"long"`
+	}
+
+	for _, v := range []interface{}{nil, badDefault{}, noPrecision{}, badOption{}, wrongBranch{}, map[int]string{}, []interface{}{}, struct{ A int }{}} {
+		_, err := SchemaOf(v)
+		assert.Error(t, err, "%T", v)
+	}
+}
@@ -0,0 +1,229 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Incompatibility is a reason a reader schema cannot read data written with a writer schema.  Path is the location in the
+//reader schema: field names separated by dots, [] for array items and {} for map values, empty for the top level schema.
+type Incompatibility struct {
+	Path    string
+	Message string
+}
+
+func (i Incompatibility) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+
+	return i.Path + ": " + i.Message
+}
+
+//CanRead applies the avro schema resolution rules and returns every reason data written with writer cannot be read with
+//reader.  No incompatibilities means reader can read all data written with writer.
+func CanRead(reader, writer Schema) []Incompatibility {
+	r := &resolver{checked: make(map[[2]string]bool)}
+	r.check("", reader, writer)
+	return r.incompatibilities
+}
+
+type resolver struct {
+	checked           map[[2]string]bool
+	incompatibilities []Incompatibility
+}
+
+func (r *resolver) fail(path string, format string, args ...interface{}) {
+	r.incompatibilities = append(r.incompatibilities, Incompatibility{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+//JoinPath appends an element to the path of a part of a schema, like the paths of Incompatibility and Change.  Field
+//names are separated by dots, the [] of array items and {} of map values are not.
+func JoinPath(path, element string) string {
+	if path == "" || strings.HasPrefix(element, "[") || strings.HasPrefix(element, "{") {
+		return path + element
+	}
+
+	return path + "." + element
+}
+
+//promotable returns whether a writer primitive can be read as a reader primitive
+func promotable(reader, writer Type) bool {
+	if reader == writer {
+		return true
+	}
+
+	switch writer {
+	case Int:
+		return reader == Long || reader == Float || reader == Double
+	case Long:
+		return reader == Float || reader == Double
+	case Float:
+		return reader == Double
+	case String:
+		return reader == Bytes
+	case Bytes:
+		return reader == String
+	}
+
+	return false
+}
+
+//namesMatch is the avro rule for named schemas: the unqualified names are the same or the reader has an alias for the writer
+func namesMatch(reader, writer NamedSchema) bool {
+	if unqualified(reader.FullName()) == unqualified(writer.FullName()) {
+		return true
+	}
+
+	for _, alias := range reader.FullAliases() {
+		if alias == writer.FullName() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func unqualified(fullName string) string {
+	_, name := SplitFullName(fullName)
+	return name
+}
+
+//matches is whether reader could be chosen to read writer, without looking inside records, arrays or maps
+func matches(reader, writer Schema) bool {
+	switch writer := writer.(type) {
+	case *PrimitiveSchema:
+		reader, ok := reader.(*PrimitiveSchema)
+		return ok && promotable(reader.Primitive, writer.Primitive)
+	case NamedSchema:
+		reader, ok := reader.(NamedSchema)
+		return ok && (reader.Type() == writer.Type() || isRecord(reader) && isRecord(writer)) && namesMatch(reader, writer)
+	default:
+		return reader.Type() == writer.Type()
+	}
+}
+
+func isRecord(s Schema) bool {
+	_, ok := s.(*RecordSchema)
+	return ok
+}
+
+func (r *resolver) check(path string, reader, writer Schema) {
+	if writerUnion, ok := writer.(*UnionSchema); ok {
+		for _, branch := range writerUnion.Types {
+			r.check(path, reader, branch)
+		}
+		return
+	}
+
+	if readerUnion, ok := reader.(*UnionSchema); ok {
+		if branch, ok := readerBranch(readerUnion, writer); ok {
+			r.check(path, branch, writer)
+			return
+		}
+
+		r.fail(path, "reader union %v has no branch that can read writer type %v", unionNames(readerUnion), TypeName(writer))
+		return
+	}
+
+	if !matches(reader, writer) {
+		if readerNamed, ok := reader.(NamedSchema); ok && reader.Type() == writer.Type() {
+			r.fail(path, "reader %v %v does not match writer %v", reader.Type(), readerNamed.FullName(), TypeName(writer))
+			return
+		}
+
+		r.fail(path, "reader type %v cannot read writer type %v", TypeName(reader), TypeName(writer))
+		return
+	}
+
+	switch reader := reader.(type) {
+	case *RecordSchema:
+		r.record(path, reader, writer.(*RecordSchema))
+	case *EnumSchema:
+		writer := writer.(*EnumSchema)
+		for _, symbol := range writer.Symbols {
+			if _, ok := reader.Symbol(symbol); !ok && !reader.HasDefault {
+				r.fail(path, "reader enum %v has no symbol %v and no default", reader.FullName(), symbol)
+			}
+		}
+	case *FixedSchema:
+		writer := writer.(*FixedSchema)
+		if reader.Size != writer.Size {
+			r.fail(path, "reader fixed %v has size %v, writer has size %v", reader.FullName(), reader.Size, writer.Size)
+		}
+	case *ArraySchema:
+		r.check(JoinPath(path, "[]"), reader.Items, writer.(*ArraySchema).Items)
+	case *MapSchema:
+		r.check(JoinPath(path, "{}"), reader.Values, writer.(*MapSchema).Values)
+	}
+}
+
+//readerBranch is the branch of a reader union that reads a writer schema that is not a union: the first branch with
+//the same type, or the first that can be promoted to if there is none
+func readerBranch(reader *UnionSchema, writer Schema) (Schema, bool) {
+	for _, branch := range reader.Types {
+		if branch.Type() == writer.Type() && matches(branch, writer) {
+			return branch, true
+		}
+	}
+
+	for _, branch := range reader.Types {
+		if matches(branch, writer) {
+			return branch, true
+		}
+	}
+
+	return nil, false
+}
+
+func (r *resolver) record(path string, reader, writer *RecordSchema) {
+	//recursive records only need checking once per pair of names
+	key := [2]string{reader.FullName(), writer.FullName()}
+	if r.checked[key] {
+		return
+	}
+	r.checked[key] = true
+
+	for _, field := range reader.Fields {
+		fieldPath := JoinPath(path, field.Name)
+
+		writerField, ok := WriterField(field, writer)
+		if !ok {
+			if !field.HasDefault {
+				r.fail(fieldPath, "reader field %v has no default and is missing from writer %v", field.Name, writer.FullName())
+			}
+			continue
+		}
+
+		r.check(fieldPath, field.Type, writerField.Type)
+	}
+}
+
+//WriterField finds the field of a writer record that a reader field reads: the field with the same name or, failing
+//that, one named by an alias of the reader field
+func WriterField(field *Field, writer *RecordSchema) (*Field, bool) {
+	if writerField, ok := writer.Field(field.Name); ok {
+		return writerField, true
+	}
+
+	for _, alias := range field.Aliases {
+		if writerField, ok := writer.Field(alias); ok {
+			return writerField, true
+		}
+	}
+
+	return nil, false
+}
+
+func unionNames(union *UnionSchema) string {
+	names := make([]string, len(union.Types))
+	for i, branch := range union.Types {
+		names[i] = TypeName(branch)
+	}
+
+	return "[" + strings.Join(names, ", ") + "]"
+}
@@ -0,0 +1,102 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func canRead(reader, writer string) []string {
+	var reasons []string
+	for _, incompatibility := range CanRead(MustParse(reader), MustParse(writer)) {
+		reasons = append(reasons, incompatibility.String())
+	}
+	return reasons
+}
+
+func TestCanReadPrimitives(t *testing.T) {
+	assert.Empty(t, canRead(`"long"`, `"int"`))
+	assert.Empty(t, canRead(`"double"`, `"float"`))
+	assert.Empty(t, canRead(`"bytes"`, `"string"`))
+	assert.Empty(t, canRead(`"string"`, `"bytes"`))
+	assert.Equal(t, []string{"reader type int cannot read writer type long"}, canRead(`"int"`, `"long"`))
+	assert.Equal(t, []string{"reader type boolean cannot read writer type string"}, canRead(`"boolean"`, `"string"`))
+}
+
+const person = `{"type": "record", "name": "Person", "namespace": "com.mm", "fields": [
+	{"name": "name", "type": "string"},
+	{"name": "age", "type": "int"}
+]}`
+
+func TestCanReadRecords(t *testing.T) {
+	added := `{"type": "record", "name": "Person", "namespace": "com.mm", "fields": [
+	{"name": "name", "type": "string"},
+	{"name": "age", "type": "int"},
+	{"name": "email", "type": ["null", "string"], "default": null}
+]}`
+	assert.Empty(t, canRead(added, person), "new fields with defaults can be read")
+	assert.Empty(t, canRead(person, added), "removed fields are skipped")
+
+	addedWithoutDefault := `{"type": "record", "name": "Person", "namespace": "com.mm", "fields": [
+	{"name": "name", "type": "string"},
+	{"name": "age", "type": "int"},
+	{"name": "email", "type": "string"}
+]}`
+	assert.Equal(t, []string{"email: reader field email has no default and is missing from writer com.mm.Person"}, canRead(addedWithoutDefault, person))
+
+	renamed := `{"type": "record", "name": "Human", "namespace": "other", "aliases": ["com.mm.Person"], "fields": [
+	{"name": "fullName", "type": "string", "aliases": ["name"]},
+	{"name": "age", "type": "int"}
+]}`
+	assert.Empty(t, canRead(renamed, person), "aliases resolve renamed records and fields")
+
+	otherName := `{"type": "record", "name": "Robot", "namespace": "com.mm", "fields": []}`
+	assert.Equal(t, []string{"reader record com.mm.Robot does not match writer com.mm.Person"}, canRead(otherName, person))
+}
+
+func TestCanReadNested(t *testing.T) {
+	writer := `{"type": "record", "name": "Event", "fields": [
+	{"name": "tags", "type": {"type": "array", "items": "string"}},
+	{"name": "counts", "type": {"type": "map", "values": "long"}},
+	{"name": "owner", "type": ` + person + `},
+	{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN", "BLUE"]}},
+	{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 16}}
+]}`
+	reader := `{"type": "record", "name": "Event", "fields": [
+	{"name": "tags", "type": {"type": "array", "items": "int"}},
+	{"name": "counts", "type": {"type": "map", "values": "int"}},
+	{"name": "owner", "type": {"type": "record", "name": "Person", "namespace": "com.mm", "fields": [{"name": "age", "type": "string"}]}},
+	{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN"]}},
+	{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 8}}
+]}`
+
+	assert.Equal(t, []string{
+		"tags[]: reader type int cannot read writer type string",
+		"counts{}: reader type int cannot read writer type long",
+		"owner.age: reader type string cannot read writer type int",
+		"color: reader enum Color has no symbol BLUE and no default",
+		"hash: reader fixed Hash has size 8, writer has size 16",
+	}, canRead(reader, writer))
+
+	readerWithDefault := `{"type": "enum", "name": "Color", "symbols": ["RED", "UNKNOWN"], "default": "UNKNOWN"}`
+	assert.Empty(t, canRead(readerWithDefault, `{"type": "enum", "name": "Color", "symbols": ["RED", "BLUE"]}`))
+}
+
+func TestCanReadUnions(t *testing.T) {
+	assert.Empty(t, canRead(`["null", "string"]`, `"string"`))
+	assert.Empty(t, canRead(`["null", "long"]`, `"int"`), "union branches may promote")
+	assert.Empty(t, canRead(`["null", "string", "long"]`, `["long", "null"]`))
+	assert.Equal(t, []string{"reader type string cannot read writer type null"}, canRead(`"string"`, `["null", "string"]`))
+	assert.Equal(t, []string{"reader union [null, string] has no branch that can read writer type int"}, canRead(`["null", "string"]`, `["null", "int"]`))
+}
+
+func TestCanReadRecursive(t *testing.T) {
+	list := `{"type": "record", "name": "List", "fields": [{"name": "value", "type": "int"}, {"name": "next", "type": ["null", "List"]}]}`
+	longList := `{"type": "record", "name": "List", "fields": [{"name": "value", "type": "long"}, {"name": "next", "type": ["null", "List"]}]}`
+	assert.Empty(t, canRead(longList, list))
+	assert.Equal(t, []string{"value: reader type int cannot read writer type long"}, canRead(list, longList))
+}
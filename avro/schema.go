@@ -0,0 +1,336 @@
+//Package avro is a model of avro schemas.  Parse turns the text the schema registry stores into a tree of Schema values
+//that the rest of the package, and the sr package, use to inspect, compare and validate schemas without a registry.
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import "strings"
+
+//Type is the type of an avro schema as it is written in the type attribute
+type Type string
+
+const (
+	//Null is the avro null primitive
+	Null = Type("null")
+	//Boolean is the avro boolean primitive
+	Boolean = Type("boolean")
+	//Int is the avro 32 bit signed integer primitive
+	Int = Type("int")
+	//Long is the avro 64 bit signed integer primitive
+	Long = Type("long")
+	//Float is the avro 32 bit floating point primitive
+	Float = Type("float")
+	//Double is the avro 64 bit floating point primitive
+	Double = Type("double")
+	//Bytes is the avro byte sequence primitive
+	Bytes = Type("bytes")
+	//String is the avro unicode string primitive
+	String = Type("string")
+	//Record is a named avro type with fields
+	Record = Type("record")
+	//Error is a record used as an error in avro protocols
+	Error = Type("error")
+	//Enum is a named avro type with a fixed set of symbols
+	Enum = Type("enum")
+	//Array is an avro list of items
+	Array = Type("array")
+	//Map is an avro map of string keys to values
+	Map = Type("map")
+	//Fixed is a named avro type with a fixed number of bytes
+	Fixed = Type("fixed")
+	//Union is an avro union, it has no type attribute and is written as a JSON array
+	Union = Type("union")
+)
+
+//IsPrimitive returns whether t is one of the avro primitive types
+func (t Type) IsPrimitive() bool {
+	switch t {
+	case Null, Boolean, Int, Long, Float, Double, Bytes, String:
+		return true
+	}
+
+	return false
+}
+
+//Schema is a parsed avro schema
+type Schema interface {
+	//Type is the avro type of the schema
+	Type() Type
+	//Position is where the schema was defined, or the zero Position for schemas not built by Parse
+	Position() Position
+}
+
+//NamedSchema is a record, enum or fixed
+type NamedSchema interface {
+	Schema
+	//FullName is the namespace qualified name of the schema
+	FullName() string
+	//FullAliases are the aliases of the schema qualified with its namespace
+	FullAliases() []string
+}
+
+//Logical types defined by the avro specification
+const (
+	Decimal              = "decimal"
+	UUID                 = "uuid"
+	Date                 = "date"
+	TimeMillis           = "time-millis"
+	TimeMicros           = "time-micros"
+	TimestampMillis      = "timestamp-millis"
+	TimestampMicros      = "timestamp-micros"
+	LocalTimestampMillis = "local-timestamp-millis"
+	LocalTimestampMicros = "local-timestamp-micros"
+	Duration             = "duration"
+)
+
+//LogicalType annotates a primitive or fixed schema with a higher level meaning
+type LogicalType struct {
+	Name string
+	//Precision and Scale are only set for decimals
+	Precision int
+	Scale     int
+}
+
+//Properties are the attributes of a schema or field that have no meaning to avro
+type Properties map[string]interface{}
+
+type node struct {
+	pos Position
+}
+
+//Position is where the schema or field was defined
+func (n node) Position() Position {
+	return n.pos
+}
+
+//PrimitiveSchema is null, boolean, int, long, float, double, bytes or string
+type PrimitiveSchema struct {
+	node
+	Primitive Type
+	Logical   *LogicalType
+	Props     Properties
+}
+
+//Type returns the primitive type
+func (s *PrimitiveSchema) Type() Type {
+	return s.Primitive
+}
+
+func qualifiedAliases(aliases []string, namespace string) []string {
+	qualified := make([]string, len(aliases))
+	for i, alias := range aliases {
+		qualified[i] = fullName(alias, namespace)
+	}
+
+	return qualified
+}
+
+//SplitFullName splits the full name of a named schema into its namespace, which is empty if it has none, and name
+func SplitFullName(fullName string) (namespace, name string) {
+	dot := strings.LastIndex(fullName, ".")
+	if dot < 0 {
+		return "", fullName
+	}
+
+	return fullName[:dot], fullName[dot+1:]
+}
+
+func fullName(name, namespace string) string {
+	if namespace == "" || strings.Contains(name, ".") {
+		return name
+	}
+
+	return namespace + "." + name
+}
+
+//RecordSchema is an avro record or error
+type RecordSchema struct {
+	node
+	Name      string
+	Namespace string
+	Aliases   []string
+	Doc       string
+	Fields    []*Field
+	IsError   bool
+	Props     Properties
+}
+
+//Type returns Record, or Error for error records
+func (s *RecordSchema) Type() Type {
+	if s.IsError {
+		return Error
+	}
+
+	return Record
+}
+
+//FullName is the namespace qualified name
+func (s *RecordSchema) FullName() string {
+	return fullName(s.Name, s.Namespace)
+}
+
+//FullAliases are the aliases qualified with the namespace
+func (s *RecordSchema) FullAliases() []string {
+	return qualifiedAliases(s.Aliases, s.Namespace)
+}
+
+//Field returns the field with name
+func (s *RecordSchema) Field(name string) (*Field, bool) {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+
+	return nil, false
+}
+
+//Order is the sort order of a record field
+type Order string
+
+const (
+	//Ascending is the default field sort order
+	Ascending = Order("ascending")
+	//Descending reverses the sort order of the field
+	Descending = Order("descending")
+	//Ignore excludes the field from sorting
+	Ignore = Order("ignore")
+)
+
+//Field is a field of a record.  Default is only meaningful if HasDefault is set, it holds the values encoding/json
+//produces for the default with numbers as json.Number.
+type Field struct {
+	node
+	Name       string
+	Doc        string
+	Type       Schema
+	Default    interface{}
+	HasDefault bool
+	Order      Order
+	Aliases    []string
+	Props      Properties
+}
+
+//EnumSchema is an avro enum
+type EnumSchema struct {
+	node
+	Name       string
+	Namespace  string
+	Aliases    []string
+	Doc        string
+	Symbols    []string
+	Default    string
+	HasDefault bool
+	Props      Properties
+}
+
+//Type returns Enum
+func (s *EnumSchema) Type() Type {
+	return Enum
+}
+
+//FullName is the namespace qualified name
+func (s *EnumSchema) FullName() string {
+	return fullName(s.Name, s.Namespace)
+}
+
+//FullAliases are the aliases qualified with the namespace
+func (s *EnumSchema) FullAliases() []string {
+	return qualifiedAliases(s.Aliases, s.Namespace)
+}
+
+//Symbol returns the index of symbol
+func (s *EnumSchema) Symbol(symbol string) (int, bool) {
+	for i, candidate := range s.Symbols {
+		if candidate == symbol {
+			return i, true
+		}
+	}
+
+	return -1, false
+}
+
+//FixedSchema is an avro fixed
+type FixedSchema struct {
+	node
+	Name      string
+	Namespace string
+	Aliases   []string
+	Doc       string
+	Size      int
+	Logical   *LogicalType
+	Props     Properties
+}
+
+//Type returns Fixed
+func (s *FixedSchema) Type() Type {
+	return Fixed
+}
+
+//FullName is the namespace qualified name
+func (s *FixedSchema) FullName() string {
+	return fullName(s.Name, s.Namespace)
+}
+
+//FullAliases are the aliases qualified with the namespace
+func (s *FixedSchema) FullAliases() []string {
+	return qualifiedAliases(s.Aliases, s.Namespace)
+}
+
+//ArraySchema is an avro array
+type ArraySchema struct {
+	node
+	Items Schema
+	Props Properties
+}
+
+//Type returns Array
+func (s *ArraySchema) Type() Type {
+	return Array
+}
+
+//MapSchema is an avro map, keys are always strings
+type MapSchema struct {
+	node
+	Values Schema
+	Props  Properties
+}
+
+//Type returns Map
+func (s *MapSchema) Type() Type {
+	return Map
+}
+
+//UnionSchema is an avro union
+type UnionSchema struct {
+	node
+	Types []Schema
+}
+
+//Type returns Union
+func (s *UnionSchema) Type() Type {
+	return Union
+}
+
+//Nullable returns whether the union has a null branch
+func (s *UnionSchema) Nullable() bool {
+	for _, t := range s.Types {
+		if t.Type() == Null {
+			return true
+		}
+	}
+
+	return false
+}
+
+//TypeName is how a schema is referred to in error messages, unions and JSON encodings: the full name of named
+//schemas and the type of others
+func TypeName(s Schema) string {
+	if named, ok := s.(NamedSchema); ok {
+		return named.FullName()
+	}
+
+	return string(s.Type())
+}
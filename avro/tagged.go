@@ -0,0 +1,93 @@
+package avro
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"reflect"
+	"strings"
+)
+
+//TaggedUnion embedded as the first field of a struct makes the struct a value of a union for Marshal and Unmarshal.
+//Its other fields are pointers tagged with the type name of a branch, like `avro:"string"` or `avro:"com.mm.Person"`,
+//and at most one of them is set.  None being set is null.
+//
+//	type NameOrID struct {
+//		avro.TaggedUnion
+//		String *string `avro:"string"`
+//		Long   *int64  `avro:"long"`
+//	}
+type TaggedUnion struct{}
+
+var taggedUnionType = reflect.TypeOf(TaggedUnion{})
+
+func isTaggedUnion(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.NumField() > 0 && t.Field(0).Anonymous && t.Field(0).Type == taggedUnionType
+}
+
+//branchName is the type name of the branch the field of a tagged union is for
+func branchName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("avro"), ",")[0]; name != "" {
+		return name
+	}
+
+	return field.Name
+}
+
+//taggedBranch returns the field of a tagged union for a branch
+func taggedBranch(rv reflect.Value, branch Schema) (reflect.Value, bool) {
+	for i := 1; i < rv.NumField(); i++ {
+		if branchName(rv.Type().Field(i)) == TypeName(branch) {
+			return rv.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func encodeTaggedUnion(buf []byte, path string, union *UnionSchema, rv reflect.Value) ([]byte, error) {
+	set := 0
+	for i := 1; i < rv.NumField(); i++ {
+		if field := rv.Field(i); field.Kind() == reflect.Ptr && !field.IsNil() {
+			if set > 0 {
+				return nil, codecError(path, "%v has both %v and %v set", rv.Type(), rv.Type().Field(set).Name, rv.Type().Field(i).Name)
+			}
+			set = i
+		}
+	}
+
+	if set == 0 {
+		return encodeUnion(buf, path, union, reflect.Value{})
+	}
+
+	name := branchName(rv.Type().Field(set))
+	for i, branch := range union.Types {
+		if TypeName(branch) == name {
+			return encode(appendLong(buf, int64(i)), path, branch, rv.Field(set))
+		}
+	}
+
+	return nil, codecError(path, "%v is not a branch of union %v", name, Describe(union))
+}
+
+//taggedField returns the field of rv, a tagged union or a pointer to one, to decode a branch other than null into
+func taggedField(path string, branch Schema, rv reflect.Value) (reflect.Value, error) {
+	if rv.Kind() == reflect.Ptr {
+		rv.Set(reflect.New(rv.Type().Elem()))
+		rv = rv.Elem()
+	}
+
+	rv.Set(reflect.Zero(rv.Type()))
+	field, ok := taggedBranch(rv, branch)
+	if !ok {
+		return reflect.Value{}, codecError(path, "%v has no field for branch %v", rv.Type(), TypeName(branch))
+	}
+
+	return field, nil
+}
+
+//isTaggedTarget is whether rv is a tagged union or a pointer to one
+func isTaggedTarget(rv reflect.Value) bool {
+	return isTaggedUnion(rv.Type()) || (rv.Kind() == reflect.Ptr && isTaggedUnion(rv.Type().Elem()))
+}
@@ -0,0 +1,226 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+//Latest is the version string the schema registry uses for the most recent version of a subject
+const Latest = "latest"
+
+//DefaultCacheSize is the number of entries kept per lookup type when NewCache is given a size of 0
+const DefaultCacheSize = 1000
+
+//Cache wraps a schema registry and remembers the answers to lookups.  Schemas are immutable by id and numeric versions,
+//so those are kept until evicted.  Lookups of the 'latest' version are only cached when LatestTTL is set.  Concurrent
+//misses for the same key result in a single call to the registry.
+type Cache struct {
+	//LatestTTL is how long a 'latest' lookup is cached for.  Zero disables caching of 'latest'.  Set it before use.
+	LatestTTL time.Duration
+
+	//Disk, if set, is written to on every schema fetched by id and read from when the registry is unavailable.  Set it before use.
+	Disk *DiskCache
+
+	client HTTPClient
+	url    string
+
+	schemas  *lru
+	versions *lru
+	ids      *lru
+	flight   flightGroup
+
+	hits   uint64
+	misses uint64
+}
+
+//CacheStats are the hit and miss counts of a Cache
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+type versionEntry struct {
+	id     uint32
+	schema Schema
+}
+
+type idEntry struct {
+	version int
+	id      int
+}
+
+//NewCache returns a Cache holding at most size entries per lookup type in front of the registry at url
+func NewCache(client HTTPClient, url string, size int) *Cache {
+	if size == 0 {
+		size = DefaultCacheSize
+	}
+
+	return &Cache{
+		client:   client,
+		url:      url,
+		schemas:  newLRU(size),
+		versions: newLRU(size),
+		ids:      newLRU(size),
+	}
+}
+
+//Stats returns the number of lookups answered from the cache and the number that went to the registry
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
+
+//GetSchema returns a schema for an id
+func (c *Cache) GetSchema(id uint32) (Schema, error) {
+	key := fmt.Sprintf("%v", id)
+	if schema, ok := c.schemas.get(key); ok {
+		c.hit()
+		return schema.(Schema), nil
+	}
+
+	c.miss()
+	result, _, err := c.flight.do("schema/"+key, func() (interface{}, error) {
+		schema, err := GetSchema(c.client, c.url, id)
+		if err == nil && schema != EmptySchema {
+			c.schemas.add(key, schema, 0)
+			c.toDisk(id, schema)
+		}
+
+		if IsUnavailable(err) && c.Disk != nil {
+			if onDisk, ok, diskErr := c.Disk.Get(id); diskErr == nil && ok {
+				c.schemas.add(key, onDisk, 0)
+				return onDisk, nil
+			}
+		}
+
+		return schema, err
+	})
+
+	if err != nil {
+		return EmptySchema, err
+	}
+
+	return result.(Schema), nil
+}
+
+//GetLatestSchema returns the latest schema and id for a subject
+func (c *Cache) GetLatestSchema(subject Subject) (uint32, Schema, error) {
+	return c.GetVersion(subject, Latest)
+}
+
+//GetVersion returns a schema and id for a subject and version.  Only numeric versions and, if LatestTTL is set, 'latest' are cached.
+func (c *Cache) GetVersion(subject Subject, version string) (uint32, Schema, error) {
+	var ttl time.Duration
+	if version == Latest {
+		if c.LatestTTL <= 0 {
+			c.miss()
+			return GetVersion(c.client, c.url, subject, version)
+		}
+		ttl = c.LatestTTL
+	} else if _, err := strconv.Atoi(version); err != nil {
+		c.miss()
+		return GetVersion(c.client, c.url, subject, version)
+	}
+
+	key := cacheKey(string(subject), version)
+	if entry, ok := c.versions.get(key); ok {
+		c.hit()
+		return entry.(versionEntry).id, entry.(versionEntry).schema, nil
+	}
+
+	c.miss()
+	result, _, err := c.flight.do("version/"+key, func() (interface{}, error) {
+		id, schema, err := GetVersion(c.client, c.url, subject, version)
+		if err == nil {
+			c.versions.add(key, versionEntry{id: id, schema: schema}, ttl)
+			c.schemas.add(fmt.Sprintf("%v", id), schema, 0)
+		}
+		return versionEntry{id: id, schema: schema}, err
+	})
+
+	if err != nil {
+		return 0, EmptySchema, err
+	}
+
+	entry := result.(versionEntry)
+	return entry.id, entry.schema, nil
+}
+
+//HasSchema returns the version and id for a schema on a subject
+func (c *Cache) HasSchema(subject Subject, schema Schema) (int, int, error) {
+	key := cacheKey(string(subject), string(schema))
+	if entry, ok := c.ids.get(key); ok && entry.(idEntry).version != 0 {
+		c.hit()
+		return entry.(idEntry).version, entry.(idEntry).id, nil
+	}
+
+	c.miss()
+	result, _, err := c.flight.do("has/"+key, func() (interface{}, error) {
+		version, id, err := HasSchema(c.client, c.url, subject, schema)
+		if err == nil && version != 0 && id != 0 {
+			c.ids.add(key, idEntry{version: version, id: id}, 0)
+			c.schemas.add(fmt.Sprintf("%v", id), schema, 0)
+		}
+		return idEntry{version: version, id: id}, err
+	})
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	entry := result.(idEntry)
+	return entry.version, entry.id, nil
+}
+
+//Register adds a schema to a subject and returns the id.  A schema already registered or checked through this cache is not sent again.
+func (c *Cache) Register(subject Subject, schema Schema) (uint32, error) {
+	key := cacheKey(string(subject), string(schema))
+	if entry, ok := c.ids.get(key); ok {
+		c.hit()
+		return uint32(entry.(idEntry).id), nil
+	}
+
+	c.miss()
+	result, _, err := c.flight.do("register/"+key, func() (interface{}, error) {
+		id, err := Register(c.client, c.url, subject, schema)
+		if err == nil {
+			c.ids.add(key, idEntry{id: int(id)}, 0)
+			c.schemas.add(fmt.Sprintf("%v", id), schema, 0)
+		}
+		return id, err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.(uint32), nil
+}
+
+//toDisk is best effort, failing to write to disk should not fail a lookup that succeeded
+func (c *Cache) toDisk(id uint32, schema Schema) {
+	if c.Disk != nil {
+		_ = c.Disk.Put(id, schema)
+	}
+}
+
+func (c *Cache) hit() {
+	atomic.AddUint64(&c.hits, 1)
+}
+
+func (c *Cache) miss() {
+	atomic.AddUint64(&c.misses, 1)
+}
+
+func cacheKey(parts ...string) string {
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,178 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countingServer(calls *int32, delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		time.Sleep(delay)
+
+		switch {
+		case r.URL.Path == "/schemas/ids/7":
+			fmt.Fprint(w, `{"schema":"\"long\""}`)
+		case r.URL.Path == "/subjects/goo/versions/3" || r.URL.Path == "/subjects/goo/versions/latest":
+			fmt.Fprint(w, `{"subject":"goo","version":3,"id":7,"schema":"\"long\""}`)
+		case r.URL.Path == "/subjects/goo/versions" && r.Method == "POST":
+			fmt.Fprint(w, `{"id":7}`)
+		case r.URL.Path == "/subjects/goo" && r.Method == "POST":
+			fmt.Fprint(w, `{"subject":"goo","version":3,"id":7,"schema":"\"long\""}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error_code":40403,"message":"Schema not found"}`)
+		}
+	}))
+}
+
+func TestCacheGetSchema(t *testing.T) {
+	var calls int32
+	ts := countingServer(&calls, 0)
+	defer ts.Close()
+
+	cache := NewCache(tstClient(), ts.URL, 10)
+	for i := 0; i < 3; i++ {
+		schema, err := cache.GetSchema(7)
+		require.NoError(t, err)
+		assert.Equal(t, Schema(`"long"`), schema)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1}, cache.Stats())
+}
+
+func TestCacheGetSchemaDeduplicatesConcurrentMisses(t *testing.T) {
+	var calls int32
+	ts := countingServer(&calls, 50*time.Millisecond)
+	defer ts.Close()
+
+	cache := NewCache(tstClient(), ts.URL, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			schema, err := cache.GetSchema(7)
+			assert.NoError(t, err)
+			assert.Equal(t, Schema(`"long"`), schema)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCacheGetVersionPopulatesSchemas(t *testing.T) {
+	var calls int32
+	ts := countingServer(&calls, 0)
+	defer ts.Close()
+
+	cache := NewCache(tstClient(), ts.URL, 10)
+	id, schema, err := cache.GetVersion(Subject("goo"), "3")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), id)
+	assert.Equal(t, Schema(`"long"`), schema)
+
+	_, _, err = cache.GetVersion(Subject("goo"), "3")
+	require.NoError(t, err)
+
+	schema, err = cache.GetSchema(7)
+	require.NoError(t, err)
+	assert.Equal(t, Schema(`"long"`), schema)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCacheLatest(t *testing.T) {
+	var calls int32
+	ts := countingServer(&calls, 0)
+	defer ts.Close()
+
+	cache := NewCache(tstClient(), ts.URL, 10)
+	for i := 0; i < 2; i++ {
+		_, _, err := cache.GetLatestSchema(Subject("goo"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "latest is not cached without a ttl")
+
+	cache.LatestTTL = 20 * time.Millisecond
+	for i := 0; i < 2; i++ {
+		_, _, err := cache.GetLatestSchema(Subject("goo"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	time.Sleep(30 * time.Millisecond)
+	_, _, err := cache.GetLatestSchema(Subject("goo"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls), "latest should expire")
+}
+
+func TestCacheRegisterAndHasSchema(t *testing.T) {
+	var calls int32
+	ts := countingServer(&calls, 0)
+	defer ts.Close()
+
+	cache := NewCache(tstClient(), ts.URL, 10)
+	id, err := cache.Register(Subject("goo"), Schema(`"long"`))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), id)
+
+	id, err = cache.Register(Subject("goo"), Schema(`"long"`))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), id)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	version, hasID, err := cache.HasSchema(Subject("goo"), Schema(`"long"`))
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+	assert.Equal(t, 7, hasID)
+
+	_, _, err = cache.HasSchema(Subject("goo"), Schema(`"long"`))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCacheErrorsAreNotCached(t *testing.T) {
+	var calls int32
+	ts := countingServer(&calls, 0)
+	defer ts.Close()
+
+	cache := NewCache(tstClient(), ts.URL, 10)
+	for i := 0; i < 2; i++ {
+		_, _, err := cache.GetVersion(Subject("missing"), "1")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestLRUEvicts(t *testing.T) {
+	l := newLRU(2)
+	l.add("a", 1, 0)
+	l.add("b", 2, 0)
+	_, ok := l.get("a")
+	require.True(t, ok)
+	l.add("c", 3, 0)
+
+	_, ok = l.get("b")
+	assert.False(t, ok, "b was least recently used")
+	_, ok = l.get("a")
+	assert.True(t, ok)
+	_, ok = l.get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, l.len())
+}
@@ -0,0 +1,81 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"context"
+	"net/http"
+)
+
+//Operation names the schema registry call a request is for
+type Operation string
+
+const (
+	//OpUnknown is the operation of requests not built by this package
+	OpUnknown = Operation("unknown")
+	//OpGetSchema is GET /schemas/ids/<id>
+	OpGetSchema = Operation("get_schema")
+	//OpRegister is POST /subjects/<subject>/versions
+	OpRegister = Operation("register")
+	//OpGetVersion is GET /subjects/<subject>/versions/<version>
+	OpGetVersion = Operation("get_version")
+	//OpHasSchema is POST /subjects/<subject>
+	OpHasSchema = Operation("has_schema")
+	//OpIsCompatible is POST /compatibility/subjects/<subject>/versions/<version>
+	OpIsCompatible = Operation("is_compatible")
+	//OpListSubjects is GET /subjects
+	OpListSubjects = Operation("list_subjects")
+	//OpListVersions is GET /subjects/<subject>/versions
+	OpListVersions = Operation("list_versions")
+	//OpGetConfig is GET /config
+	OpGetConfig = Operation("get_config")
+	//OpGetSubjectConfig is GET /config/<subject>
+	OpGetSubjectConfig = Operation("get_subject_config")
+	//OpPutSubjectConfig is PUT /config/<subject>
+	OpPutSubjectConfig = Operation("put_subject_config")
+	//OpListContexts is GET /contexts
+	OpListContexts = Operation("list_contexts")
+	//OpGetSubjectsByID is GET /schemas/ids/<id>/subjects
+	OpGetSubjectsByID = Operation("get_subjects_by_id")
+	//OpGetVersionsByID is GET /schemas/ids/<id>/versions
+	OpGetVersionsByID = Operation("get_versions_by_id")
+	//OpReferencedBy is GET /subjects/<subject>/versions/<version>/referencedby
+	OpReferencedBy = Operation("referenced_by")
+)
+
+//Call describes the schema registry call a request was built for.  Fields that do not apply to the operation are empty.
+type Call struct {
+	Operation Operation
+	Subject   Subject
+	Version   string
+	ID        uint32
+}
+
+type callKey struct{}
+
+//CallOf returns the Call a request was built for.  Requests not built by this package have the OpUnknown operation.
+func CallOf(request *http.Request) Call {
+	if call, ok := request.Context().Value(callKey{}).(Call); ok {
+		return call
+	}
+
+	return Call{Operation: OpUnknown}
+}
+
+func withCall(request *http.Request, call Call) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), callKey{}, call))
+}
+
+//WithContext makes every request through the client in ctx, so its calls are canceled with ctx and traced as children
+//of the span in ctx.  It goes before WithTracing in a Chain, or wraps a client that is already chained:
+//
+//	Register(WithContext(ctx)(client), url, subject, schema)
+func WithContext(ctx context.Context) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(request *http.Request) (*http.Response, error) {
+			return next.Do(request.WithContext(context.WithValue(ctx, callKey{}, CallOf(request))))
+		})
+	}
+}
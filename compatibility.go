@@ -18,4 +18,13 @@ const (
 
 	//Backward compatibility means the compatible schema can read all previous schemas
 	Backward = Compatibility("BACKWARD")
+
+	//BackwardTransitive means the compatible schema can read data written with every previous schema, not just the latest
+	BackwardTransitive = Compatibility("BACKWARD_TRANSITIVE")
+
+	//ForwardTransitive means every previous schema, not just the latest, can read data written with the compatible schema
+	ForwardTransitive = Compatibility("FORWARD_TRANSITIVE")
+
+	//FullTransitive means both BackwardTransitive and ForwardTransitive compatibility is expected
+	FullTransitive = Compatibility("FULL_TRANSITIVE")
 )
@@ -0,0 +1,105 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+//Context is a namespace of the schema registry with its own subjects, schema ids and configs, so one registry can keep
+//tenants apart.  Contexts start with a dot.  Subjects are put in a context by qualifying them, :.context:subject, or by
+//making calls against the ContextURL of the context.
+type Context string
+
+//DefaultContext is the context of subjects that are not qualified
+const DefaultContext = Context(".")
+
+//ContextNamed returns the context called name, with or without its leading dot.  The empty name is the DefaultContext.
+func ContextNamed(name string) Context {
+	if name == "" || name == string(DefaultContext) {
+		return DefaultContext
+	}
+
+	if !strings.HasPrefix(name, ".") {
+		name = "." + name
+	}
+
+	return Context(name)
+}
+
+//Validate returns an error if the context cannot qualify a subject: it does not start with a dot or has a : / or
+//control characters
+func (c Context) Validate() error {
+	switch {
+	case !strings.HasPrefix(string(c), "."):
+		return fmt.Errorf("context %q does not start with a .", string(c))
+	case strings.ContainsAny(string(c), ":/"):
+		return fmt.Errorf("context %q has a : or /", string(c))
+	case strings.IndexFunc(string(c), unicode.IsControl) >= 0:
+		return fmt.Errorf("context %q has control characters", string(c))
+	}
+
+	return nil
+}
+
+//Qualify returns subject in c, :.context:subject, adding the leading dot if c has none.  Subjects are not qualified
+//for the DefaultContext and a subject that is already qualified is returned as is.
+func (c Context) Qualify(subject Subject) Subject {
+	c = ContextNamed(string(c))
+	if c == DefaultContext || strings.HasPrefix(string(subject), ":.") {
+		return subject
+	}
+
+	return Subject(":" + string(c) + ":" + string(subject))
+}
+
+//Context splits a :.context:subject subject into its context and the subject in that context.  Subjects that are not
+//qualified are in the DefaultContext.
+func (s Subject) Context() (Context, Subject) {
+	if !strings.HasPrefix(string(s), ":.") {
+		return DefaultContext, s
+	}
+
+	end := strings.Index(string(s[1:]), ":")
+	if end < 0 {
+		return DefaultContext, s
+	}
+
+	return Context(s[1 : end+1]), s[end+2:]
+}
+
+//ContextURL returns the url of the registry at url that operates inside c, every call made against it reads and writes
+//the subjects, schema ids and configs of c only.  The url of the DefaultContext is url itself.
+func ContextURL(url string, c Context) (string, error) {
+	c = ContextNamed(string(c))
+	if c == DefaultContext {
+		return url, nil
+	}
+
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+
+	return buildURL(url, endpoint("contexts", string(c)))
+}
+
+//ListContextsRequest returns the http.Request for the GET /contexts route
+func ListContextsRequest(baseURL string) (*http.Request, error) {
+	return get(baseURL, "contexts", Call{Operation: OpListContexts})
+}
+
+//ListContexts returns the contexts of the registry, which include the DefaultContext
+func ListContexts(client HTTPClient, url string) (contexts []Context, err error) {
+	var req *http.Request
+	req, err = ListContextsRequest(url)
+	if err == nil {
+		_, _, err = doJSON(client, req, &contexts)
+	}
+
+	return
+}
@@ -0,0 +1,97 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextSubjects(t *testing.T) {
+	assert.Equal(t, DefaultContext, ContextNamed(""))
+	assert.Equal(t, Context(".tenant"), ContextNamed("tenant"))
+	assert.Equal(t, Context(".tenant"), ContextNamed(".tenant"))
+
+	assert.Equal(t, Subject(":.tenant:users-value"), Context(".tenant").Qualify("users-value"))
+	assert.Equal(t, Subject(":.tenant:users-value"), Context("tenant").Qualify("users-value"))
+	assert.Equal(t, Subject(":.other:users-value"), Context(".tenant").Qualify(":.other:users-value"), "already qualified")
+	assert.Equal(t, Subject("users-value"), DefaultContext.Qualify("users-value"))
+	assert.Equal(t, Subject("users-value"), Context("").Qualify("users-value"))
+
+	subjects := map[Subject][2]string{
+		":.tenant:users-value": {".tenant", "users-value"},
+		":.a.b:x:y":            {".a.b", "x:y"},
+		"users-value":          {".", "users-value"},
+		":tenant:users":        {".", ":tenant:users"},
+		":.tenant":             {".", ":.tenant"},
+	}
+	for subject, expected := range subjects {
+		context, unqualified := subject.Context()
+		assert.Equal(t, Context(expected[0]), context, string(subject))
+		assert.Equal(t, Subject(expected[1]), unqualified, string(subject))
+	}
+
+	assert.NoError(t, Subject(":.tenant:users-value").Validate())
+	assert.Error(t, Subject(":.tenant:").Validate())
+	assert.Error(t, Context("tenant").Validate())
+	assert.Error(t, Context(".a/b").Validate())
+
+	name, strategy := ParseSubject(":.tenant:users-key")
+	assert.Equal(t, SubjectName{Context: ".tenant", Topic: "users", Key: true}, name)
+	assert.Equal(t, TopicNameStrategy{}, strategy)
+}
+
+func TestContextURL(t *testing.T) {
+	u, err := ContextURL("http://example.com/registry", "tenant")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/registry/contexts/.tenant", u)
+
+	request, err := ListVersionsRequest(u, "a/b")
+	require.NoError(t, err)
+	assert.Equal(t, "/registry/contexts/.tenant/subjects/a%2Fb/versions", request.URL.EscapedPath())
+
+	u, err = ContextURL("http://example.com", DefaultContext)
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com", u)
+
+	_, err = ContextURL("http://example.com", ".a:b")
+	assert.Error(t, err)
+}
+
+func TestContexts(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	tenant, err := ContextURL(ts.URL, "tenant")
+	require.NoError(t, err)
+
+	_, err = Register(tstClient(), ts.URL, "users-value", userV1)
+	require.NoError(t, err)
+
+	_, err = Register(tstClient(), tenant, "users-value", userV2)
+	require.NoError(t, err)
+
+	_, schema, err := GetLatestSchema(tstClient(), ts.URL, ":.tenant:users-value")
+	require.NoError(t, err)
+	assert.Equal(t, userV2, schema, "registered in the context")
+
+	_, schema, err = GetLatestSchema(tstClient(), ts.URL, "users-value")
+	require.NoError(t, err)
+	assert.Equal(t, userV1, schema)
+
+	contexts, err := ListContexts(tstClient(), ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []Context{".", ".tenant"}, contexts)
+
+	subjects, err := ListSubjects(tstClient(), tenant)
+	require.NoError(t, err)
+	assert.Equal(t, []Subject{":.tenant:users-value"}, subjects)
+
+	subjects, err = ListSubjects(tstClient(), ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []Subject{"users-value"}, subjects)
+}
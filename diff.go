@@ -0,0 +1,41 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+
+	"github.com/MediaMath/sr/avro"
+)
+
+//DiffSchemas returns the semantic differences between two avro schemas by field path, ignoring formatting
+func DiffSchemas(before, after Schema) ([]avro.Change, error) {
+	parsedBefore, err := avro.Parse(string(before))
+	if err != nil {
+		return nil, fmt.Errorf("before: %v", err)
+	}
+
+	parsedAfter, err := avro.Parse(string(after))
+	if err != nil {
+		return nil, fmt.Errorf("after: %v", err)
+	}
+
+	return avro.Diff(parsedBefore, parsedAfter), nil
+}
+
+//DiffVersions returns the semantic differences between two registered versions of a subject
+func DiffVersions(client HTTPClient, url string, subject Subject, before, after string) ([]avro.Change, error) {
+	_, beforeSchema, err := GetVersion(client, url, subject, before)
+	if err != nil {
+		return nil, err
+	}
+
+	_, afterSchema, err := GetVersion(client, url, subject, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffSchemas(beforeSchema, afterSchema)
+}
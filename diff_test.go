@@ -0,0 +1,53 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MediaMath/sr/avro"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	changes, err := DiffSchemas(recordV1, recordV2)
+	require.NoError(t, err)
+	assert.Equal(t, []avro.Change{{Path: "b", Kind: avro.FieldAdded, New: "string"}}, changes)
+
+	changes, err = DiffSchemas(recordV1, Schema(`{"fields": [{"type": "int", "name": "a"}], "name": "R", "type": "record"}`))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = DiffSchemas(recordV1, Schema(`{`))
+	assert.Error(t, err)
+}
+
+func TestDiffVersions(t *testing.T) {
+	versions := map[string]Schema{"/subjects/goo/versions/1": recordV1, "/subjects/goo/versions/2": recordV3}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		schema, ok := versions[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"error_code":40402,"message":"Version not found."}`, 404)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "schema": schema})
+	}))
+	defer ts.Close()
+
+	changes, err := DiffVersions(tstClient(), ts.URL, Subject("goo"), "1", "2")
+	require.NoError(t, err)
+	assert.Equal(t, []avro.Change{
+		{Path: "b", Kind: avro.FieldAdded, New: "string"},
+		{Path: "a", Kind: avro.FieldRemoved, Old: "int"},
+	}, changes)
+
+	_, err = DiffVersions(tstClient(), ts.URL, Subject("goo"), "1", "3")
+	assert.Error(t, err)
+}
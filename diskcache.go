@@ -0,0 +1,155 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+//DiskCache is a directory of schemas keyed by id.  Each schema is kept in its own file alongside a checksum, files are
+//replaced atomically so several processes can share a directory.  A file that fails its checksum is treated as missing.
+type DiskCache struct {
+	dir string
+}
+
+type diskCacheFile struct {
+	ID     uint32 `json:"id"`
+	SHA256 string `json:"sha256"`
+	Schema Schema `json:"schema"`
+}
+
+//NewDiskCache returns a DiskCache in dir, creating the directory if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &DiskCache{dir: dir}, nil
+}
+
+//Dir is the directory the cache is stored in
+func (d *DiskCache) Dir() string {
+	return d.dir
+}
+
+//Get returns the schema stored for id.  ok is false if there is no valid file for id.
+func (d *DiskCache) Get(id uint32) (schema Schema, ok bool, err error) {
+	data, err := ioutil.ReadFile(d.path(id))
+	if os.IsNotExist(err) {
+		return EmptySchema, false, nil
+	}
+
+	if err != nil {
+		return EmptySchema, false, err
+	}
+
+	var file diskCacheFile
+	if json.Unmarshal(data, &file) != nil || file.ID != id || file.SHA256 != checksum(file.Schema) {
+		return EmptySchema, false, nil
+	}
+
+	return file.Schema, true, nil
+}
+
+//Put stores schema for id
+func (d *DiskCache) Put(id uint32, schema Schema) error {
+	data, err := json.Marshal(&diskCacheFile{ID: id, SHA256: checksum(schema), Schema: schema})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(d.dir, fmt.Sprintf(".%v-*.tmp", id))
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), d.path(id))
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+//IDs returns the ids of all schemas in the cache
+func (d *DiskCache) IDs() ([]uint32, error) {
+	matches, err := filepath.Glob(filepath.Join(d.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint32
+	for _, match := range matches {
+		name := filepath.Base(match)
+		id, err := strconv.ParseUint(name[:len(name)-len(".json")], 10, 32)
+		if err == nil {
+			ids = append(ids, uint32(id))
+		}
+	}
+
+	return ids, nil
+}
+
+//Warm stores every version of the subjects provided, or of all subjects if none are provided, and returns the number of schemas stored
+func (d *DiskCache) Warm(client HTTPClient, url string, subjects ...Subject) (int, error) {
+	var total int
+
+	if len(subjects) == 0 {
+		var err error
+		subjects, err = ListSubjects(client, url)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	for _, subject := range subjects {
+		versions, err := ListVersions(client, url, subject)
+		if err != nil {
+			return total, err
+		}
+
+		for _, version := range versions {
+			id, schema, err := GetVersion(client, url, subject, strconv.Itoa(version))
+			if err != nil {
+				return total, err
+			}
+
+			if err = d.Put(id, schema); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+func (d *DiskCache) path(id uint32) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%v.json", id))
+}
+
+func checksum(schema Schema) string {
+	sum := sha256.Sum256([]byte(schema))
+	return hex.EncodeToString(sum[:])
+}
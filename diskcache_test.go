@@ -0,0 +1,126 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tstDiskCache(t *testing.T) *DiskCache {
+	dir, err := ioutil.TempDir("", "sr-disk-cache")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	disk, err := NewDiskCache(dir)
+	require.NoError(t, err)
+	return disk
+}
+
+func TestDiskCachePutGet(t *testing.T) {
+	disk := tstDiskCache(t)
+
+	_, ok, err := disk.Get(7)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, disk.Put(7, Schema(`"long"`)))
+	schema, ok, err := disk.Get(7)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Schema(`"long"`), schema)
+
+	ids, err := disk.IDs()
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{7}, ids)
+}
+
+func TestDiskCacheIgnoresCorruptFiles(t *testing.T) {
+	disk := tstDiskCache(t)
+
+	corrupt := `{"id":7,"sha256":"0000","schema":"\"long\""}`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(disk.Dir(), "7.json"), []byte(corrupt), 0644))
+
+	_, ok, err := disk.Get(7)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCacheFallsBackToDisk(t *testing.T) {
+	var calls int32
+	ts := countingServer(&calls, 0)
+	url := ts.URL
+
+	disk := tstDiskCache(t)
+	cache := NewCache(tstClient(), url, 10)
+	cache.Disk = disk
+
+	_, err := cache.GetSchema(7)
+	require.NoError(t, err)
+	ts.Close()
+
+	offline := NewCache(tstClient(), url, 10)
+	_, err = offline.GetSchema(7)
+	require.Error(t, err)
+	assert.True(t, IsUnavailable(err), err.Error())
+
+	offline.Disk = disk
+	schema, err := offline.GetSchema(7)
+	require.NoError(t, err)
+	assert.Equal(t, Schema(`"long"`), schema)
+}
+
+func TestCacheFallsBackToDiskOnServerError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, "down for maintenance")
+	}))
+	defer ts.Close()
+
+	disk := tstDiskCache(t)
+	require.NoError(t, disk.Put(7, Schema(`"long"`)))
+
+	cache := NewCache(tstClient(), ts.URL, 10)
+	cache.Disk = disk
+	schema, err := cache.GetSchema(7)
+	require.NoError(t, err)
+	assert.Equal(t, Schema(`"long"`), schema)
+}
+
+func TestDiskCacheWarm(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subjects":
+			fmt.Fprint(w, `["goo"]`)
+		case "/subjects/goo/versions":
+			fmt.Fprint(w, `[1,2]`)
+		case "/subjects/goo/versions/1":
+			fmt.Fprint(w, `{"id":3,"schema":"\"int\""}`)
+		case "/subjects/goo/versions/2":
+			fmt.Fprint(w, `{"id":4,"schema":"\"long\""}`)
+		default:
+			http.Error(w, fmt.Sprintf("Wrong path: %v", r.URL.Path), 500)
+		}
+	}))
+	defer ts.Close()
+
+	disk := tstDiskCache(t)
+	total, err := disk.Warm(tstClient(), ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	schema, ok, err := disk.Get(4)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Schema(`"long"`), schema)
+}
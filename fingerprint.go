@@ -0,0 +1,65 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/MediaMath/sr/avro"
+)
+
+//Fingerprint identifies a schema by the content of its Parsing Canonical Form
+type Fingerprint struct {
+	//CRC64 is the CRC-64-AVRO fingerprint
+	CRC64  uint64
+	MD5    [16]byte
+	SHA256 [32]byte
+}
+
+//String is the fingerprints in hex, one per line
+func (f Fingerprint) String() string {
+	return fmt.Sprintf("crc64-avro %016x\nmd5 %s\nsha256 %s", f.CRC64, hex.EncodeToString(f.MD5[:]), hex.EncodeToString(f.SHA256[:]))
+}
+
+//CanonicalForm returns the Parsing Canonical Form of an avro schema.  Schemas that differ only in formatting, docs,
+//defaults or other attributes that do not change how data is read or written have the same canonical form.
+func CanonicalForm(schema Schema) (Schema, error) {
+	parsed, err := avro.Parse(string(schema))
+	if err != nil {
+		return EmptySchema, err
+	}
+
+	return Schema(avro.Canonical(parsed)), nil
+}
+
+//Fingerprints returns the fingerprints of the canonical form of an avro schema
+func Fingerprints(schema Schema) (Fingerprint, error) {
+	parsed, err := avro.Parse(string(schema))
+	if err != nil {
+		return Fingerprint{}, err
+	}
+
+	return Fingerprint{
+		CRC64:  avro.Fingerprint64(parsed),
+		MD5:    avro.FingerprintMD5(parsed),
+		SHA256: avro.FingerprintSHA256(parsed),
+	}, nil
+}
+
+//SameCanonicalForm returns whether two avro schemas have the same canonical form, without asking a registry
+func SameCanonicalForm(a, b Schema) (bool, error) {
+	canonicalA, err := CanonicalForm(a)
+	if err != nil {
+		return false, err
+	}
+
+	canonicalB, err := CanonicalForm(b)
+	if err != nil {
+		return false, err
+	}
+
+	return canonicalA == canonicalB, nil
+}
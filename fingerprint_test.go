@@ -0,0 +1,34 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSameCanonicalForm(t *testing.T) {
+	formatted := TestSchema(1)
+	compact := Schema(`{"type":"record","name":"unit_test_functional_1","namespace":"com.mediamath.sr","fields":[{"name":"foo","type":"long"},{"name":"bar","type":"string"}]}`)
+
+	same, err := SameCanonicalForm(formatted, compact)
+	require.NoError(t, err)
+	assert.True(t, same)
+
+	same, err = SameCanonicalForm(formatted, TestSchema(2))
+	require.NoError(t, err)
+	assert.False(t, same)
+
+	_, err = SameCanonicalForm(formatted, Schema(`{"type":`))
+	assert.Error(t, err)
+}
+
+func TestFingerprints(t *testing.T) {
+	fingerprint, err := Fingerprints(Schema(`{"type": "int"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "crc64-avro 7275d51a3f395c8f\nmd5 ef524ea1b91e73173d938ade36c1db32\nsha256 3f2b87a9fe7cc9b13835598c3981cd45e3e355309e5090aa0933d7becb6fba45", fingerprint.String())
+}
@@ -0,0 +1,406 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/MediaMath/sr/avro"
+	"gopkg.in/yaml.v3"
+)
+
+//Severity is how serious a lint finding is
+type Severity string
+
+const (
+	//SeverityError findings should fail a build
+	SeverityError = Severity("error")
+	//SeverityWarning findings should be looked at
+	SeverityWarning = Severity("warning")
+	//SeverityInfo findings are suggestions
+	SeverityInfo = Severity("info")
+	//SeverityOff turns a rule off
+	SeverityOff = Severity("off")
+)
+
+//Lint rule ids
+const (
+	//LintParse is reported for schemas that cannot be parsed, it cannot be turned off
+	LintParse = "parse"
+	//LintDocRequired requires a doc on every record, enum and field
+	LintDocRequired = "doc-required"
+	//LintNamespace requires records, enums and fixed to have a namespace matching the rule pattern, or any
+	//namespace if there is no pattern
+	LintNamespace = "namespace"
+	//LintSnakeCase requires field names to match the rule pattern, snake_case by default
+	LintSnakeCase = "snake-case"
+	//LintNullableUnion requires unions with null to list null first and fields of those unions to default to null
+	LintNullableUnion = "nullable-union"
+	//LintNewFieldDefault requires fields that are not in the previous schema to have a default
+	LintNewFieldDefault = "new-field-default"
+)
+
+//DefaultSnakeCasePattern is the pattern LintSnakeCase uses when there is none configured
+const DefaultSnakeCasePattern = `^[a-z][a-z0-9]*(_[a-z0-9]+)*$`
+
+//LintRule is the configuration of a single rule.  In YAML it is either a mapping with severity and pattern or just
+//the severity.
+type LintRule struct {
+	Severity Severity `yaml:"severity" json:"severity"`
+	Pattern  string   `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+}
+
+//UnmarshalYAML allows a rule to be configured with only its severity
+func (r *LintRule) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&r.Severity)
+	}
+
+	//KnownFields does not apply to nodes decoded here
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		if key := value.Content[i].Value; key != "severity" && key != "pattern" {
+			return fmt.Errorf("line %v: unknown lint rule attribute %q", value.Content[i].Line, key)
+		}
+	}
+
+	type plain LintRule
+	return value.Decode((*plain)(r))
+}
+
+//LintConfig is the rule set of a Linter by rule id.  Rules that are not configured use their default.
+//
+//	rules:
+//	  doc-required: warning
+//	  namespace:
+//	    severity: error
+//	    pattern: '^com\.mediamath(\.[a-z0-9_]+)*$'
+//	  new-field-default: off
+type LintConfig struct {
+	Rules map[string]LintRule `yaml:"rules" json:"rules"`
+}
+
+//DefaultLintConfig is the rule set used for rules that are not configured
+func DefaultLintConfig() LintConfig {
+	return LintConfig{Rules: map[string]LintRule{
+		LintDocRequired:     {Severity: SeverityWarning},
+		LintNamespace:       {Severity: SeverityError},
+		LintSnakeCase:       {Severity: SeverityError, Pattern: DefaultSnakeCasePattern},
+		LintNullableUnion:   {Severity: SeverityError},
+		LintNewFieldDefault: {Severity: SeverityError},
+	}}
+}
+
+//ReadLintConfig reads a YAML rule set.  Unknown rules, attributes and severities are errors.
+func ReadLintConfig(r io.Reader) (LintConfig, error) {
+	var config LintConfig
+
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return config, err
+	}
+
+	defaults := DefaultLintConfig()
+	for id, rule := range config.Rules {
+		if _, ok := defaults.Rules[id]; !ok {
+			return config, fmt.Errorf("unknown lint rule %q", id)
+		}
+
+		switch rule.Severity {
+		case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		default:
+			return config, fmt.Errorf("lint rule %q has unknown severity %q", id, rule.Severity)
+		}
+	}
+
+	return config, nil
+}
+
+//LintFinding is a rule violation in a schema.  Line and Column are 0 when the position is unknown.
+type LintFinding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"`
+	Path     string   `json:"path"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func (f LintFinding) String() string {
+	location := f.File
+	if f.Line > 0 {
+		location = fmt.Sprintf("%v:%v:%v", location, f.Line, f.Column)
+	}
+
+	return fmt.Sprintf("%v: %v %v: %v", strings.TrimPrefix(location, ":"), f.Severity, f.Rule, f.Message)
+}
+
+//GitHubAnnotation is the finding as a GitHub Actions workflow command, which annotates the file in pull requests
+func (f LintFinding) GitHubAnnotation() string {
+	command := "notice"
+	switch f.Severity {
+	case SeverityError:
+		command = "error"
+	case SeverityWarning:
+		command = "warning"
+	}
+
+	var properties []string
+	if f.File != "" {
+		properties = append(properties, "file="+escapeAnnotationProperty(f.File))
+	}
+	if f.Line > 0 {
+		properties = append(properties, fmt.Sprintf("line=%v", f.Line), fmt.Sprintf("col=%v", f.Column))
+	}
+	properties = append(properties, "title="+escapeAnnotationProperty(f.Rule))
+
+	return fmt.Sprintf("::%v %v::%v", command, strings.Join(properties, ","), escapeAnnotationData(f.Message))
+}
+
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(escapeAnnotationData(s))
+}
+
+//HasErrors is true if any of the findings is an error
+func HasErrors(findings []LintFinding) bool {
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Linter checks avro schemas against a rule set
+type Linter struct {
+	rules     map[string]LintRule
+	namespace *regexp.Regexp
+	snakeCase *regexp.Regexp
+}
+
+//NewLinter returns a Linter for config, rules not in config use DefaultLintConfig
+func NewLinter(config LintConfig) (*Linter, error) {
+	rules := DefaultLintConfig().Rules
+	for id, rule := range config.Rules {
+		if rule.Pattern == "" {
+			rule.Pattern = rules[id].Pattern
+		}
+		rules[id] = rule
+	}
+
+	linter := &Linter{rules: rules}
+
+	var err error
+	if pattern := rules[LintNamespace].Pattern; pattern != "" {
+		if linter.namespace, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%v pattern: %v", LintNamespace, err)
+		}
+	}
+
+	if linter.snakeCase, err = regexp.Compile(rules[LintSnakeCase].Pattern); err != nil {
+		return nil, fmt.Errorf("%v pattern: %v", LintSnakeCase, err)
+	}
+
+	return linter, nil
+}
+
+//LintSchema parses and lints a schema.  A schema that does not parse is a LintParse finding, previous is the
+//schema it replaces, or EmptySchema, and must parse.
+func (l *Linter) LintSchema(schema Schema, previous Schema) ([]LintFinding, error) {
+	var parsedPrevious avro.Schema
+	if previous != EmptySchema {
+		var err error
+		if parsedPrevious, err = avro.Parse(string(previous)); err != nil {
+			return nil, fmt.Errorf("previous: %v", err)
+		}
+	}
+
+	parsed, err := avro.Parse(string(schema))
+	if err != nil {
+		finding := LintFinding{Rule: LintParse, Severity: SeverityError, Path: ".", Message: err.Error()}
+
+		var parseError *avro.ParseError
+		if errors.As(err, &parseError) {
+			finding.Message = parseError.Message
+			finding.Line, finding.Column = parseError.Pos.Line, parseError.Pos.Column
+		}
+
+		return []LintFinding{finding}, nil
+	}
+
+	return l.Lint(parsed, parsedPrevious), nil
+}
+
+//Lint checks a parsed schema, previous is the schema it replaces or nil.  Findings are ordered by position.
+func (l *Linter) Lint(schema avro.Schema, previous avro.Schema) []LintFinding {
+	run := &lintRun{Linter: l, visited: make(map[avro.NamedSchema]bool), previous: make(map[string]*avro.RecordSchema)}
+	if previous != nil {
+		collectRecords(previous, run.previous, make(map[avro.NamedSchema]bool))
+	}
+
+	run.lint("", schema)
+
+	sort.SliceStable(run.findings, func(i, j int) bool {
+		a, b := run.findings[i], run.findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return run.findings
+}
+
+type lintRun struct {
+	*Linter
+	visited  map[avro.NamedSchema]bool
+	previous map[string]*avro.RecordSchema
+	findings []LintFinding
+}
+
+func (r *lintRun) report(id, path string, pos avro.Position, format string, args ...interface{}) {
+	severity := r.rules[id].Severity
+	if severity == SeverityOff || severity == "" {
+		return
+	}
+
+	if path == "" {
+		path = "."
+	}
+
+	r.findings = append(r.findings, LintFinding{
+		Rule:     id,
+		Severity: severity,
+		Path:     path,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *lintRun) lint(path string, schema avro.Schema) {
+	if named, ok := schema.(avro.NamedSchema); ok {
+		if r.visited[named] {
+			return
+		}
+		r.visited[named] = true
+		r.lintNamespace(path, named)
+	}
+
+	switch s := schema.(type) {
+	case *avro.RecordSchema:
+		if s.Doc == "" {
+			r.report(LintDocRequired, path, s.Position(), "record %v has no doc", s.FullName())
+		}
+
+		for _, field := range s.Fields {
+			r.lintField(avro.JoinPath(path, field.Name), s, field)
+		}
+	case *avro.EnumSchema:
+		if s.Doc == "" {
+			r.report(LintDocRequired, path, s.Position(), "enum %v has no doc", s.FullName())
+		}
+	case *avro.ArraySchema:
+		r.lint(avro.JoinPath(path, "[]"), s.Items)
+	case *avro.MapSchema:
+		r.lint(avro.JoinPath(path, "{}"), s.Values)
+	case *avro.UnionSchema:
+		for i, branch := range s.Types {
+			if branch.Type() == avro.Null && i > 0 {
+				r.report(LintNullableUnion, path, s.Position(), "null must be the first branch of union %v", avro.Describe(s))
+			}
+			r.lint(path, branch)
+		}
+	}
+}
+
+func (r *lintRun) lintNamespace(path string, named avro.NamedSchema) {
+	namespace := ""
+	if i := strings.LastIndex(named.FullName(), "."); i >= 0 {
+		namespace = named.FullName()[:i]
+	}
+
+	switch {
+	case namespace == "":
+		r.report(LintNamespace, path, named.Position(), "%v has no namespace", named.FullName())
+	case r.namespace != nil && !r.namespace.MatchString(namespace):
+		r.report(LintNamespace, path, named.Position(), "namespace %v of %v does not match %v", namespace, named.FullName(), r.namespace)
+	}
+}
+
+func (r *lintRun) lintField(path string, record *avro.RecordSchema, field *avro.Field) {
+	if field.Doc == "" {
+		r.report(LintDocRequired, path, field.Position(), "field %v has no doc", field.Name)
+	}
+
+	if !r.snakeCase.MatchString(field.Name) {
+		r.report(LintSnakeCase, path, field.Position(), "field name %v does not match %v", field.Name, r.snakeCase)
+	}
+
+	if union, ok := field.Type.(*avro.UnionSchema); ok && len(union.Types) > 0 && union.Types[0].Type() == avro.Null {
+		if !field.HasDefault || field.Default != nil {
+			r.report(LintNullableUnion, path, field.Position(), "optional field %v should default to null", field.Name)
+		}
+	}
+
+	if previous, ok := r.previousRecord(record); ok && !field.HasDefault {
+		if _, existed := avro.WriterField(field, previous); !existed {
+			r.report(LintNewFieldDefault, path, field.Position(), "new field %v has no default", field.Name)
+		}
+	}
+
+	r.lint(path, field.Type)
+}
+
+//previousRecord finds the record in the previous schema by full name or alias
+func (r *lintRun) previousRecord(record *avro.RecordSchema) (*avro.RecordSchema, bool) {
+	if previous, ok := r.previous[record.FullName()]; ok {
+		return previous, true
+	}
+
+	for _, alias := range record.FullAliases() {
+		if previous, ok := r.previous[alias]; ok {
+			return previous, true
+		}
+	}
+
+	return nil, false
+}
+
+func collectRecords(schema avro.Schema, records map[string]*avro.RecordSchema, visited map[avro.NamedSchema]bool) {
+	if named, ok := schema.(avro.NamedSchema); ok {
+		if visited[named] {
+			return
+		}
+		visited[named] = true
+	}
+
+	switch s := schema.(type) {
+	case *avro.RecordSchema:
+		records[s.FullName()] = s
+		for _, field := range s.Fields {
+			collectRecords(field.Type, records, visited)
+		}
+	case *avro.ArraySchema:
+		collectRecords(s.Items, records, visited)
+	case *avro.MapSchema:
+		collectRecords(s.Values, records, visited)
+	case *avro.UnionSchema:
+		for _, branch := range s.Types {
+			collectRecords(branch, records, visited)
+		}
+	}
+}
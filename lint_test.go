@@ -0,0 +1,106 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lint(t *testing.T, config string, schema, previous Schema) []string {
+	lintConfig, err := ReadLintConfig(strings.NewReader(config))
+	require.NoError(t, err)
+
+	linter, err := NewLinter(lintConfig)
+	require.NoError(t, err)
+
+	findings, err := linter.LintSchema(schema, previous)
+	require.NoError(t, err)
+
+	var results []string
+	for _, finding := range findings {
+		results = append(results, finding.String())
+	}
+	return results
+}
+
+func TestLintDefaults(t *testing.T) {
+	schema := Schema(`{"type": "record", "name": "Event", "fields": [
+	{"name": "eventId", "type": "long", "doc": "the id"},
+	{"name": "owner", "type": ["string", "null"], "doc": "who"},
+	{"name": "email", "type": ["null", "string"], "doc": "how to reach them"}
+]}`)
+
+	assert.Equal(t, []string{
+		"1:1: error namespace: Event has no namespace",
+		"1:1: warning doc-required: record Event has no doc",
+		"2:2: error snake-case: field name eventId does not match ^[a-z][a-z0-9]*(_[a-z0-9]+)*$",
+		"3:28: error nullable-union: null must be the first branch of union [string, null]",
+		"4:2: error nullable-union: optional field email should default to null",
+	}, lint(t, "", schema, EmptySchema))
+}
+
+func TestLintConfig(t *testing.T) {
+	config := `
+rules:
+  doc-required: off
+  namespace:
+    severity: warning
+    pattern: '^com\.mediamath(\.[a-z0-9_]+)*$'
+  snake-case: info
+`
+	schema := Schema(`{"type": "record", "name": "Event", "namespace": "org.other", "fields": [
+	{"name": "eventId", "type": "long"},
+	{"name": "color", "type": {"type": "enum", "name": "com.mediamath.Color", "symbols": ["RED"]}}
+]}`)
+
+	assert.Equal(t, []string{
+		"1:1: warning namespace: namespace org.other of org.other.Event does not match ^com\\.mediamath(\\.[a-z0-9_]+)*$",
+		"2:2: info snake-case: field name eventId does not match ^[a-z][a-z0-9]*(_[a-z0-9]+)*$",
+	}, lint(t, config, schema, EmptySchema))
+}
+
+func TestLintNewFieldDefault(t *testing.T) {
+	config := "rules: {doc-required: off, namespace: off}"
+	previous := Schema(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`)
+	schema := Schema(`{"type": "record", "name": "R", "fields": [
+	{"name": "a", "type": "int"},
+	{"name": "b", "type": "int", "default": 0},
+	{"name": "c", "type": "int"}
+]}`)
+
+	assert.Equal(t, []string{"4:2: error new-field-default: new field c has no default"}, lint(t, config, schema, previous))
+	assert.Empty(t, lint(t, config, schema, EmptySchema))
+}
+
+func TestLintParseErrors(t *testing.T) {
+	assert.Equal(t, []string{"1:1: error parse: unknown type \"nope\""}, lint(t, "", Schema(`"nope"`), EmptySchema))
+
+	_, err := ReadLintConfig(strings.NewReader("rules: {nope: error}"))
+	assert.Error(t, err)
+
+	_, err = ReadLintConfig(strings.NewReader("rules: {doc-required: fatal}"))
+	assert.Error(t, err)
+
+	_, err = ReadLintConfig(strings.NewReader("rules: {doc-required: {severity: error, colour: red}}"))
+	assert.Error(t, err)
+
+	_, err = NewLinter(LintConfig{Rules: map[string]LintRule{LintSnakeCase: {Severity: SeverityError, Pattern: "("}}})
+	assert.Error(t, err)
+}
+
+func TestLintFindingOutput(t *testing.T) {
+	finding := LintFinding{Rule: LintDocRequired, Severity: SeverityWarning, File: "schemas/a,b.avsc", Path: "id", Line: 3, Column: 5, Message: "field id has no doc\n"}
+	assert.Equal(t, "schemas/a,b.avsc:3:5: warning doc-required: field id has no doc\n", finding.String())
+	assert.Equal(t, "::warning file=schemas/a%2Cb.avsc,line=3,col=5,title=doc-required::field id has no doc%0A", finding.GitHubAnnotation())
+
+	finding = LintFinding{Rule: LintParse, Severity: SeverityError, Message: "bad"}
+	assert.Equal(t, ": error parse: bad", finding.String())
+	assert.Equal(t, "::error title=parse::bad", finding.GitHubAnnotation())
+	assert.True(t, HasErrors([]LintFinding{finding}))
+}
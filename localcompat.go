@@ -0,0 +1,85 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+
+	"github.com/MediaMath/sr/avro"
+)
+
+//Incompatibility is a reason a schema is not compatible with one of the schemas it was checked against
+type Incompatibility struct {
+	//Previous is the index of the schema it was checked against in the previous schemas
+	Previous int
+	//Backward is true if the new schema cannot read data written with the previous schema and false if the previous
+	//schema cannot read data written with the new one
+	Backward bool
+	avro.Incompatibility
+}
+
+func (i Incompatibility) String() string {
+	if i.Backward {
+		return fmt.Sprintf("cannot read data written with previous schema %v: %v", i.Previous, i.Incompatibility)
+	}
+
+	return fmt.Sprintf("previous schema %v cannot read data written with this schema: %v", i.Previous, i.Incompatibility)
+}
+
+//CheckCompatibility applies the avro schema resolution rules to decide, without a registry, whether schema can follow the
+//previous schemas, ordered oldest first, under a compatibility level.  Non transitive levels only check against the
+//last previous schema.  No incompatibilities means the schema is compatible.
+func CheckCompatibility(level Compatibility, schema Schema, previous []Schema) ([]Incompatibility, error) {
+	var backward, forward, transitive bool
+	switch level {
+	case None:
+	case Backward:
+		backward = true
+	case Forward:
+		forward = true
+	case Full:
+		backward, forward = true, true
+	case BackwardTransitive:
+		backward, transitive = true, true
+	case ForwardTransitive:
+		forward, transitive = true, true
+	case FullTransitive:
+		backward, forward, transitive = true, true, true
+	default:
+		return nil, fmt.Errorf("Unknown compatibility level: %q", level)
+	}
+
+	parsed, err := avro.Parse(string(schema))
+	if err != nil {
+		return nil, err
+	}
+
+	first := 0
+	if !transitive && len(previous) > 0 {
+		first = len(previous) - 1
+	}
+
+	var incompatibilities []Incompatibility
+	for i := first; i < len(previous) && (backward || forward); i++ {
+		prior, err := avro.Parse(string(previous[i]))
+		if err != nil {
+			return nil, fmt.Errorf("previous schema %v: %v", i, err)
+		}
+
+		if backward {
+			for _, reason := range avro.CanRead(parsed, prior) {
+				incompatibilities = append(incompatibilities, Incompatibility{Previous: i, Backward: true, Incompatibility: reason})
+			}
+		}
+
+		if forward {
+			for _, reason := range avro.CanRead(prior, parsed) {
+				incompatibilities = append(incompatibilities, Incompatibility{Previous: i, Incompatibility: reason})
+			}
+		}
+	}
+
+	return incompatibilities, nil
+}
@@ -0,0 +1,66 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	recordV1 = Schema(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`)
+	recordV2 = Schema(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "b", "type": "string", "default": ""}]}`)
+	recordV3 = Schema(`{"type": "record", "name": "R", "fields": [{"name": "b", "type": "string", "default": ""}]}`)
+)
+
+func reasons(t *testing.T, level Compatibility, schema Schema, previous ...Schema) []string {
+	incompatibilities, err := CheckCompatibility(level, schema, previous)
+	require.NoError(t, err)
+
+	var reasons []string
+	for _, incompatibility := range incompatibilities {
+		reasons = append(reasons, incompatibility.String())
+	}
+	return reasons
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	assert.Empty(t, reasons(t, Backward, recordV2, recordV1))
+	assert.Empty(t, reasons(t, Forward, recordV2, recordV1))
+	assert.Empty(t, reasons(t, Full, recordV2, recordV1))
+	assert.Empty(t, reasons(t, None, Schema(`"int"`), recordV1))
+	assert.Empty(t, reasons(t, Backward, recordV1))
+
+	//dropping a field without a default breaks forward compatibility
+	assert.Empty(t, reasons(t, Backward, recordV3, recordV2))
+	assert.Equal(t, []string{"previous schema 0 cannot read data written with this schema: a: reader field a has no default and is missing from writer R"}, reasons(t, Forward, recordV3, recordV2))
+}
+
+func TestCheckCompatibilityTransitive(t *testing.T) {
+	recordV4 := Schema(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "b", "type": "string"}]}`)
+
+	assert.Empty(t, reasons(t, Backward, recordV4, recordV1, recordV2))
+	assert.Equal(t, []string{
+		"cannot read data written with previous schema 0: b: reader field b has no default and is missing from writer R",
+	}, reasons(t, BackwardTransitive, recordV4, recordV1, recordV2))
+
+	assert.Equal(t, []string{
+		"cannot read data written with previous schema 0: b: reader field b has no default and is missing from writer R",
+		"previous schema 0 cannot read data written with this schema: a: reader type int cannot read writer type long",
+	}, reasons(t, FullTransitive, Schema(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "long"}, {"name": "b", "type": "string"}]}`), recordV1, recordV2)[:2])
+}
+
+func TestCheckCompatibilityErrors(t *testing.T) {
+	_, err := CheckCompatibility(Compatibility("SIDEWAYS"), recordV1, nil)
+	assert.Error(t, err)
+
+	_, err = CheckCompatibility(Backward, Schema(`{`), nil)
+	assert.Error(t, err)
+
+	_, err = CheckCompatibility(Backward, recordV1, []Schema{Schema(`"nope"`)})
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+//subjectNotFound is the error_code of the registry for a subject it does not have
+const subjectNotFound = 40401
+
+//schemaNotFound is the error_code of the registry for an id it has no schema for
+const schemaNotFound = 40403
+
+//SubjectVersion is a version of a subject
+type SubjectVersion struct {
+	Subject Subject `json:"subject"`
+	Version int     `json:"version"`
+}
+
+//GetSubjectsByIDRequest returns the http.Request for the GET /schemas/ids/<id>/subjects route
+func GetSubjectsByIDRequest(baseURL string, id uint32) (*http.Request, error) {
+	return get(baseURL, endpoint("schemas", "ids", fmt.Sprintf("%v", id), "subjects"), Call{Operation: OpGetSubjectsByID, ID: id})
+}
+
+//GetVersionsByIDRequest returns the http.Request for the GET /schemas/ids/<id>/versions route
+func GetVersionsByIDRequest(baseURL string, id uint32) (*http.Request, error) {
+	return get(baseURL, endpoint("schemas", "ids", fmt.Sprintf("%v", id), "versions"), Call{Operation: OpGetVersionsByID, ID: id})
+}
+
+//GetSubjectsByID returns the subjects the schema with id is registered on
+func GetSubjectsByID(client HTTPClient, url string, id uint32) (subjects []Subject, err error) {
+	var req *http.Request
+	req, err = GetSubjectsByIDRequest(url, id)
+	if err == nil {
+		err = getByID(client, req, &subjects)
+	}
+
+	return
+}
+
+//GetVersionsByID returns the subject and version pairs the schema with id is registered as
+func GetVersionsByID(client HTTPClient, url string, id uint32) (versions []SubjectVersion, err error) {
+	var req *http.Request
+	req, err = GetVersionsByIDRequest(url, id)
+	if err == nil {
+		err = getByID(client, req, &versions)
+	}
+
+	return
+}
+
+//FindVersionsByID is GetVersionsByID for registries that may not have the /schemas/ids/<id>/versions route.  If they
+//do not, every subject is asked whether it has the schema with id, which takes a call per subject.
+func FindVersionsByID(client HTTPClient, url string, id uint32) ([]SubjectVersion, error) {
+	versions, err := GetVersionsByID(client, url, id)
+	if byID, ok := err.(*byIDError); ok && byID.status == http.StatusNotFound && byID.code != schemaNotFound {
+		return scanVersionsByID(client, url, id)
+	}
+
+	return versions, err
+}
+
+func scanVersionsByID(client HTTPClient, url string, id uint32) ([]SubjectVersion, error) {
+	schema, err := GetSchema(client, url, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if schema == EmptySchema {
+		return nil, fmt.Errorf("no schema with id %v", id)
+	}
+
+	subjects, err := ListSubjects(client, url)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []SubjectVersion{}
+	for _, subject := range subjects {
+		version, found, err := HasSchema(client, url, subject, schema)
+		if err != nil {
+			return nil, err
+		}
+
+		//subjects without the schema answer with no version
+		if version != 0 && uint32(found) == id {
+			versions = append(versions, SubjectVersion{Subject: subject, Version: version})
+		}
+	}
+
+	return versions, nil
+}
+
+//byIDError is the error response to a lookup, which FindVersionsByID and DescribeTopic need the error_code of
+type byIDError struct {
+	status int
+	code   int
+	body   []byte
+}
+
+func (e *byIDError) Error() string {
+	return fmt.Sprintf("%v:%s", e.status, e.body)
+}
+
+func getByID(client HTTPClient, req *http.Request, response interface{}) error {
+	status, body, err := doJSON(client, req, nil)
+	switch {
+	case err != nil:
+		return err
+	case status >= http.StatusInternalServerError:
+		return &UnavailableError{Status: status, Err: fmt.Errorf("%v:%s", status, body)}
+	case status != http.StatusOK:
+		errorResponse := struct {
+			ErrorCode int `json:"error_code"`
+		}{}
+		json.Unmarshal(body, &errorResponse)
+		return &byIDError{status: status, code: errorResponse.ErrorCode, body: body}
+	}
+
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("Unexpected response (%v) from %v.\n%s", status, req.URL, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupByID(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	id, err := Register(tstClient(), ts.URL, "users-value", userV1)
+	require.NoError(t, err)
+	_, err = Register(tstClient(), ts.URL, "users-value", userV2)
+	require.NoError(t, err)
+	_, err = Register(tstClient(), ts.URL, "archive-value", userV1)
+	require.NoError(t, err)
+
+	subjects, err := GetSubjectsByID(tstClient(), ts.URL, id)
+	require.NoError(t, err)
+	assert.Equal(t, []Subject{"archive-value", "users-value"}, subjects)
+
+	expected := []SubjectVersion{{Subject: "archive-value", Version: 1}, {Subject: "users-value", Version: 1}}
+	versions, err := GetVersionsByID(tstClient(), ts.URL, id)
+	require.NoError(t, err)
+	assert.Equal(t, expected, versions)
+
+	versions, err = FindVersionsByID(tstClient(), ts.URL, id)
+	require.NoError(t, err)
+	assert.Equal(t, expected, versions)
+
+	_, err = GetVersionsByID(tstClient(), ts.URL, 99)
+	assert.Error(t, err)
+
+	_, err = FindVersionsByID(tstClient(), ts.URL, 99)
+	assert.Error(t, err, "a missing schema is not a missing route")
+}
+
+func TestFindVersionsByIDScans(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	//an older registry without the routes to look schemas up by id
+	registry := ts.Config.Handler
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/subjects") && strings.HasPrefix(r.URL.Path, "/schemas/ids/") {
+			http.Error(w, `{"error_code": 404, "message": "HTTP 404 Not Found"}`, http.StatusNotFound)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/versions") && strings.HasPrefix(r.URL.Path, "/schemas/ids/") {
+			http.NotFound(w, r)
+			return
+		}
+		registry.ServeHTTP(w, r)
+	})
+
+	id, err := Register(tstClient(), ts.URL, "users-value", userV1)
+	require.NoError(t, err)
+	_, err = Register(tstClient(), ts.URL, "users-value", userV2)
+	require.NoError(t, err)
+	_, err = Register(tstClient(), ts.URL, "other-value", userV2)
+	require.NoError(t, err)
+	_, err = Register(tstClient(), ts.URL, "archive-value", userV1)
+	require.NoError(t, err)
+
+	_, err = GetVersionsByID(tstClient(), ts.URL, id)
+	require.Error(t, err)
+	_, err = GetSubjectsByID(tstClient(), ts.URL, id)
+	require.Error(t, err)
+
+	versions, err := FindVersionsByID(tstClient(), ts.URL, id)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []SubjectVersion{{Subject: "archive-value", Version: 1}, {Subject: "users-value", Version: 1}}, versions)
+
+	_, err = FindVersionsByID(tstClient(), ts.URL, 99)
+	assert.Error(t, err)
+}
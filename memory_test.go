@@ -0,0 +1,190 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//memoryRegistry is a registry server that keeps subjects, schemas and configs in memory
+func memoryRegistry() *httptest.Server {
+	var lock sync.Mutex
+	var schemas []Schema
+	subjects := make(map[string][]int)
+	configs := make(map[string]string)
+	references := make(map[int][]Reference)
+
+	notFound := func(w http.ResponseWriter, code int, message string) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error_code": code, "message": message})
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		//segments are unescaped one by one so subjects can contain a /
+		var segments []string
+		for _, segment := range strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/") {
+			unescaped, _ := url.PathUnescape(segment)
+			segments = append(segments, unescaped)
+		}
+
+		//calls against the url of a context are on the subjects qualified with it
+		context := DefaultContext
+		if len(segments) > 2 && segments[0] == "contexts" {
+			context, segments = Context(segments[1]), segments[2:]
+			if len(segments) > 1 && (segments[0] == "subjects" || segments[0] == "config") {
+				segments[1] = string(context.Qualify(Subject(segments[1])))
+			}
+		}
+
+		var body struct {
+			Schema     Schema      `json:"schema"`
+			References []Reference `json:"references"`
+		}
+		if r.Method == "POST" {
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+
+		route := r.Method + " " + segments[0]
+		switch {
+		case route == "GET schemas" && len(segments) == 3:
+			id, err := strconv.Atoi(segments[2])
+			if err != nil || id < 1 || id > len(schemas) {
+				notFound(w, 40403, "Schema not found")
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"schema": schemas[id-1]})
+		case route == "GET schemas" && len(segments) == 4 && (segments[3] == "subjects" || segments[3] == "versions"):
+			id, err := strconv.Atoi(segments[2])
+			if err != nil || id < 1 || id > len(schemas) {
+				notFound(w, 40403, "Schema not found")
+				return
+			}
+			names, versions := []string{}, []SubjectVersion{}
+			for subject, ids := range subjects {
+				for version, registered := range ids {
+					if registered == id {
+						names = append(names, subject)
+						versions = append(versions, SubjectVersion{Subject: Subject(subject), Version: version + 1})
+					}
+				}
+			}
+			sort.Strings(names)
+			sort.Slice(versions, func(i, j int) bool { return versions[i].Subject < versions[j].Subject })
+			if segments[3] == "subjects" {
+				json.NewEncoder(w).Encode(names)
+			} else {
+				json.NewEncoder(w).Encode(versions)
+			}
+		case route == "GET subjects" && len(segments) == 1:
+			names := []string{}
+			for subject := range subjects {
+				if in, _ := Subject(subject).Context(); in == context && strings.HasPrefix(subject, r.URL.Query().Get("subjectPrefix")) {
+					names = append(names, subject)
+				}
+			}
+			sort.Strings(names)
+			json.NewEncoder(w).Encode(names)
+		case route == "GET subjects" && len(segments) == 3:
+			if _, ok := subjects[segments[1]]; !ok {
+				notFound(w, 40401, "Subject not found")
+				return
+			}
+			versions := []int{}
+			for i := range subjects[segments[1]] {
+				versions = append(versions, i+1)
+			}
+			json.NewEncoder(w).Encode(versions)
+		case route == "GET subjects" && (len(segments) == 4 || len(segments) == 5 && segments[4] == "referencedby"):
+			ids := subjects[segments[1]]
+			version := len(ids)
+			if segments[3] != Latest {
+				version, _ = strconv.Atoi(segments[3])
+			}
+			if version < 1 || version > len(ids) {
+				notFound(w, 40402, "Version not found")
+				return
+			}
+			if len(segments) == 4 {
+				id := ids[version-1]
+				json.NewEncoder(w).Encode(map[string]interface{}{"subject": segments[1], "version": version, "id": id, "schema": schemas[id-1], "references": references[id]})
+				return
+			}
+			referencedBy := []int{}
+			for id := range schemas {
+				for _, reference := range references[id+1] {
+					if reference.Subject == Subject(segments[1]) && reference.Version == version {
+						referencedBy = append(referencedBy, id+1)
+						break
+					}
+				}
+			}
+			json.NewEncoder(w).Encode(referencedBy)
+		case route == "POST subjects":
+			subject := segments[1]
+			for version, id := range subjects[subject] {
+				if schemas[id-1] == body.Schema {
+					json.NewEncoder(w).Encode(map[string]interface{}{"subject": subject, "version": version + 1, "id": id, "schema": body.Schema})
+					return
+				}
+			}
+
+			if len(segments) == 2 {
+				notFound(w, 40403, "Schema not found")
+				return
+			}
+
+			id := 0
+			for i, schema := range schemas {
+				if schema == body.Schema {
+					id = i + 1
+				}
+			}
+			if id == 0 {
+				schemas = append(schemas, body.Schema)
+				id = len(schemas)
+				references[id] = body.References
+			}
+			subjects[subject] = append(subjects[subject], id)
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+		case route == "GET contexts" && len(segments) == 1:
+			found := map[Context]bool{DefaultContext: true}
+			for subject := range subjects {
+				in, _ := Subject(subject).Context()
+				found[in] = true
+			}
+			contexts := []string{}
+			for in := range found {
+				contexts = append(contexts, string(in))
+			}
+			sort.Strings(contexts)
+			json.NewEncoder(w).Encode(contexts)
+		case route == "GET config" && len(segments) == 1:
+			json.NewEncoder(w).Encode(ConfigGetJSON{Compatibility: string(Backward)})
+		case route == "GET config":
+			if _, ok := configs[segments[1]]; !ok {
+				notFound(w, 40401, "Subject not found")
+				return
+			}
+			json.NewEncoder(w).Encode(ConfigGetJSON{Compatibility: configs[segments[1]]})
+		case route == "PUT config":
+			var config ConfigPutJSON
+			json.NewDecoder(r.Body).Decode(&config)
+			configs[segments[1]] = config.Compatibility
+			json.NewEncoder(w).Encode(config)
+		default:
+			notFound(w, 404, "Not found")
+		}
+	}))
+}
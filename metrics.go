@@ -0,0 +1,200 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Metrics records schema registry calls.  Status is 0 when no response was received, errorCode is the confluent
+//error_code of the response or 0 if there was none.
+type Metrics interface {
+	ObserveCall(operation Operation, status int, errorCode int, duration time.Duration)
+}
+
+//WithMetrics records every call made through the client in metrics
+func WithMetrics(metrics Metrics) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next.Do(request)
+			elapsed := time.Since(start)
+
+			var status, errorCode int
+			if err == nil {
+				status = response.StatusCode
+				errorCode = peekErrorCode(response)
+			}
+
+			metrics.ObserveCall(CallOf(request).Operation, status, errorCode, elapsed)
+			return response, err
+		})
+	}
+}
+
+//peekErrorCode reads the confluent error_code from an error response and leaves the body readable
+func peekErrorCode(response *http.Response) int {
+	if response.StatusCode < http.StatusBadRequest || response.Body == nil {
+		return 0
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 0
+	}
+
+	errorResponse := struct {
+		ErrorCode int `json:"error_code"`
+	}{}
+
+	if json.Unmarshal(body, &errorResponse) != nil {
+		return 0
+	}
+
+	return errorResponse.ErrorCode
+}
+
+//DefaultLatencyBuckets are the histogram buckets, in seconds, used when NewPrometheusMetrics is given none
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+//PrometheusMetrics keeps call counts and latency histograms in memory and writes them in the prometheus text exposition format
+type PrometheusMetrics struct {
+	namespace string
+	buckets   []float64
+
+	mu     sync.Mutex
+	series map[metricLabels]*metricSeries
+}
+
+type metricLabels struct {
+	operation Operation
+	status    int
+	errorCode int
+}
+
+type metricSeries struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+//NewPrometheusMetrics returns metrics whose names are prefixed with namespace and whose latency histograms use buckets
+func NewPrometheusMetrics(namespace string, buckets []float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &PrometheusMetrics{
+		namespace: namespace,
+		buckets:   sorted,
+		series:    make(map[metricLabels]*metricSeries),
+	}
+}
+
+//ObserveCall records a call
+func (p *PrometheusMetrics) ObserveCall(operation Operation, status int, errorCode int, duration time.Duration) {
+	labels := metricLabels{operation: operation, status: status, errorCode: errorCode}
+	seconds := duration.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	series, ok := p.series[labels]
+	if !ok {
+		series = &metricSeries{buckets: make([]uint64, len(p.buckets))}
+		p.series[labels] = series
+	}
+
+	series.count++
+	series.sum += seconds
+	for i, bound := range p.buckets {
+		if seconds <= bound {
+			series.buckets[i]++
+		}
+	}
+}
+
+//WriteTo writes the metrics in the prometheus text exposition format
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	labels := make([]metricLabels, 0, len(p.series))
+	snapshot := make(map[metricLabels]metricSeries, len(p.series))
+	for l, series := range p.series {
+		labels = append(labels, l)
+		snapshot[l] = metricSeries{count: series.count, sum: series.sum, buckets: append([]uint64(nil), series.buckets...)}
+	}
+	p.mu.Unlock()
+
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].operation != labels[j].operation {
+			return labels[i].operation < labels[j].operation
+		}
+		if labels[i].status != labels[j].status {
+			return labels[i].status < labels[j].status
+		}
+		return labels[i].errorCode < labels[j].errorCode
+	})
+
+	var b strings.Builder
+	requests := p.name("requests_total")
+	fmt.Fprintf(&b, "# HELP %s Schema registry calls by operation, status and error code.\n", requests)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", requests)
+	for _, l := range labels {
+		fmt.Fprintf(&b, "%s{%s} %d\n", requests, l, snapshot[l].count)
+	}
+
+	duration := p.name("request_duration_seconds")
+	fmt.Fprintf(&b, "# HELP %s Schema registry call latency by operation, status and error code.\n", duration)
+	fmt.Fprintf(&b, "# TYPE %s histogram\n", duration)
+	for _, l := range labels {
+		series := snapshot[l]
+		for i, bound := range p.buckets {
+			fmt.Fprintf(&b, "%s_bucket{%s,le=\"%v\"} %d\n", duration, l, bound, series.buckets[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d\n", duration, l, series.count)
+		fmt.Fprintf(&b, "%s_sum{%s} %v\n", duration, l, series.sum)
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", duration, l, series.count)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+//ServeHTTP writes the metrics so PrometheusMetrics can be registered as a /metrics handler
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = p.WriteTo(w)
+}
+
+func (p *PrometheusMetrics) name(metric string) string {
+	if p.namespace == "" {
+		return metric
+	}
+
+	return p.namespace + "_" + metric
+}
+
+func (l metricLabels) String() string {
+	status := "none"
+	if l.status != 0 {
+		status = fmt.Sprintf("%v", l.status)
+	}
+
+	return fmt.Sprintf(`operation="%s",status="%s",error_code="%v"`, l.operation, status, l.errorCode)
+}
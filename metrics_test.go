@@ -0,0 +1,78 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallOf(t *testing.T) {
+	request, err := RegisterRequest("http://example.com", Subject("foo"), &SchemaJSON{})
+	require.NoError(t, err)
+	assert.Equal(t, OpRegister, CallOf(request).Operation)
+
+	request, err = http.NewRequest("GET", "http://example.com", nil)
+	require.NoError(t, err)
+	assert.Equal(t, OpUnknown, CallOf(request).Operation)
+}
+
+func TestWithMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/subjects" {
+			fmt.Fprint(w, `["boo"]`)
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error_code":40402,"message":"Version not found."}`)
+	}))
+	defer ts.Close()
+
+	metrics := NewPrometheusMetrics("sr", []float64{1})
+	client := Chain(tstClient(), WithMetrics(metrics))
+
+	_, err := ListSubjects(client, ts.URL)
+	require.NoError(t, err)
+
+	_, _, err = GetVersion(client, ts.URL, Subject("boo"), "9")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Version not found", "the body should still be readable after the error code is read")
+
+	var out bytes.Buffer
+	_, err = metrics.WriteTo(&out)
+	require.NoError(t, err)
+
+	exposition := out.String()
+	assert.Contains(t, exposition, "# TYPE sr_requests_total counter\n")
+	assert.Contains(t, exposition, `sr_requests_total{operation="list_subjects",status="200",error_code="0"} 1`)
+	assert.Contains(t, exposition, `sr_requests_total{operation="get_version",status="404",error_code="40402"} 1`)
+	assert.Contains(t, exposition, "# TYPE sr_request_duration_seconds histogram\n")
+	assert.Contains(t, exposition, `sr_request_duration_seconds_bucket{operation="list_subjects",status="200",error_code="0",le="1"} 1`)
+	assert.Contains(t, exposition, `sr_request_duration_seconds_bucket{operation="list_subjects",status="200",error_code="0",le="+Inf"} 1`)
+	assert.Contains(t, exposition, `sr_request_duration_seconds_count{operation="get_version",status="404",error_code="40402"} 1`)
+}
+
+func TestPrometheusMetricsBuckets(t *testing.T) {
+	metrics := NewPrometheusMetrics("", []float64{0.5, 0.1})
+	metrics.ObserveCall(OpGetSchema, 0, 0, 200*time.Millisecond)
+
+	var out bytes.Buffer
+	_, err := metrics.WriteTo(&out)
+	require.NoError(t, err)
+
+	exposition := out.String()
+	assert.Contains(t, exposition, `requests_total{operation="get_schema",status="none",error_code="0"} 1`)
+	assert.Contains(t, exposition, `request_duration_seconds_bucket{operation="get_schema",status="none",error_code="0",le="0.1"} 0`)
+	assert.Contains(t, exposition, `request_duration_seconds_bucket{operation="get_schema",status="none",error_code="0",le="0.5"} 1`)
+	assert.Contains(t, exposition, `request_duration_seconds_sum{operation="get_schema",status="none",error_code="0"} 0.2`)
+}
@@ -0,0 +1,148 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+//HTTPClientFunc lets an ordinary function be used as a HTTPClient
+type HTTPClientFunc func(request *http.Request) (*http.Response, error)
+
+//Do calls f(request)
+func (f HTTPClientFunc) Do(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+//Middleware wraps a HTTPClient to add behavior to every request made through it
+type Middleware func(HTTPClient) HTTPClient
+
+//Chain wraps client in middlewares.  The first middleware is the outermost, so it sees the request first and the response last.
+func Chain(client HTTPClient, middlewares ...Middleware) HTTPClient {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		client = middlewares[i](client)
+	}
+
+	return client
+}
+
+//WithHeaders sets headers on every request, replacing any value already present
+func WithHeaders(headers http.Header) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(request *http.Request) (*http.Response, error) {
+			request = request.Clone(request.Context())
+			for name, values := range headers {
+				request.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+			}
+
+			return next.Do(request)
+		})
+	}
+}
+
+//WithUserAgent sets the User-Agent header on every request
+func WithUserAgent(userAgent string) Middleware {
+	return WithHeaders(http.Header{"User-Agent": []string{userAgent}})
+}
+
+//RequestIDHeader is the header WithRequestID uses when none is given
+const RequestIDHeader = "X-Request-ID"
+
+//WithRequestID sets header to a value from generate on every request that does not already have it.  An empty header
+//defaults to RequestIDHeader and a nil generate defaults to a random 16 byte hex string.
+func WithRequestID(header string, generate func() string) Middleware {
+	if header == "" {
+		header = RequestIDHeader
+	}
+
+	if generate == nil {
+		generate = randomID
+	}
+
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(request *http.Request) (*http.Response, error) {
+			if request.Header.Get(header) == "" {
+				request = request.Clone(request.Context())
+				request.Header.Set(header, generate())
+			}
+
+			return next.Do(request)
+		})
+	}
+}
+
+//Logger is satisfied by *log.Logger
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+//DefaultRedactedHeaders are the headers WithLogging hides when no others are given
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+//WithLogging logs each request and response with their headers.  The values of the redacted headers are replaced,
+//if none are given DefaultRedactedHeaders are redacted.  Passwords in urls are always redacted.
+func WithLogging(logger Logger, redacted ...string) Middleware {
+	if len(redacted) == 0 {
+		redacted = DefaultRedactedHeaders
+	}
+
+	hidden := make(map[string]bool)
+	for _, name := range redacted {
+		hidden[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(request *http.Request) (*http.Response, error) {
+			//the url may have basic auth credentials in it
+			address := request.URL.Redacted()
+			logger.Printf("sr: %v %v %v", request.Method, address, formatHeaders(request.Header, hidden))
+
+			start := time.Now()
+			response, err := next.Do(request)
+			elapsed := time.Since(start)
+
+			if err != nil {
+				logger.Printf("sr: %v %v failed after %v: %v", request.Method, address, elapsed, err)
+			} else {
+				logger.Printf("sr: %v %v %v in %v %v", request.Method, address, response.StatusCode, elapsed, formatHeaders(response.Header, hidden))
+			}
+
+			return response, err
+		})
+	}
+}
+
+func formatHeaders(headers http.Header, hidden map[string]bool) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	formatted := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(headers[name], ",")
+		if hidden[http.CanonicalHeaderKey(name)] {
+			value = "[REDACTED]"
+		}
+		formatted = append(formatted, name+"="+value)
+	}
+
+	return "{" + strings.Join(formatted, " ") + "}"
+}
+
+func randomID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,106 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func headerServer(received *http.Header) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*received = r.Header.Clone()
+		fmt.Fprint(w, `["boo"]`)
+	}))
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next HTTPClient) HTTPClient {
+			return HTTPClientFunc(func(request *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.Do(request)
+			})
+		}
+	}
+
+	var received http.Header
+	ts := headerServer(&received)
+	defer ts.Close()
+
+	_, err := ListSubjects(Chain(tstClient(), mark("outer"), mark("inner")), ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, order)
+}
+
+func TestHeaderMiddlewares(t *testing.T) {
+	var received http.Header
+	ts := headerServer(&received)
+	defer ts.Close()
+
+	client := Chain(tstClient(),
+		WithUserAgent("sr-test"),
+		WithHeaders(http.Header{"X-Team": []string{"data"}}),
+		WithRequestID("", func() string { return "abc" }),
+	)
+
+	_, err := ListSubjects(client, ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "sr-test", received.Get("User-Agent"))
+	assert.Equal(t, "data", received.Get("X-Team"))
+	assert.Equal(t, "abc", received.Get(RequestIDHeader))
+}
+
+func TestRequestIDKeepsExisting(t *testing.T) {
+	var received http.Header
+	ts := headerServer(&received)
+	defer ts.Close()
+
+	client := Chain(tstClient(), WithHeaders(http.Header{"X-Request-Id": []string{"mine"}}), WithRequestID("", nil))
+	_, err := ListSubjects(client, ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "mine", received.Get(RequestIDHeader))
+}
+
+func TestLoggingRedacts(t *testing.T) {
+	var received http.Header
+	ts := headerServer(&received)
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	client := Chain(tstClient(),
+		WithHeaders(http.Header{"Authorization": []string{"Basic c2VjcmV0"}, "X-Api-Key": []string{"hunter2"}}),
+		WithLogging(log.New(&buf, "", 0), "Authorization", "x-api-key"),
+	)
+
+	_, err := ListSubjects(client, ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Basic c2VjcmV0", received.Get("Authorization"), "redaction is only for logging")
+
+	logged := buf.String()
+	assert.Equal(t, 2, strings.Count(logged, "\n"), logged)
+	assert.Contains(t, logged, "GET "+ts.URL+"/subjects")
+	assert.Contains(t, logged, "Authorization=[REDACTED]")
+	assert.Contains(t, logged, "X-Api-Key=[REDACTED]")
+	assert.NotContains(t, logged, "c2VjcmV0")
+	assert.NotContains(t, logged, "hunter2")
+	assert.Contains(t, logged, " 200 in ")
+
+	buf.Reset()
+	withPassword := strings.Replace(ts.URL, "http://", "http://ann:hunter3@", 1)
+	_, err = ListSubjects(Chain(tstClient(), WithLogging(log.New(&buf, "", 0))), withPassword)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "http://ann:xxxxx@")
+	assert.NotContains(t, buf.String(), "hunter3")
+}
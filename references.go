@@ -0,0 +1,188 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//Reference is a schema reference, the version of a subject whose schema defines the type a schema refers to by Name
+type Reference struct {
+	Name    string  `json:"name"`
+	Subject Subject `json:"subject"`
+	Version int     `json:"version"`
+}
+
+//ReferencedByRequest returns the http.Request for the GET /subjects/<subject>/versions/<version>/referencedby route
+func ReferencedByRequest(baseURL string, subject Subject, version string) (*http.Request, error) {
+	if err := subject.Validate(); err != nil {
+		return nil, err
+	}
+
+	return get(baseURL, endpoint("subjects", string(subject), "versions", version, "referencedby"), Call{Operation: OpReferencedBy, Subject: subject, Version: version})
+}
+
+//ReferencedBy returns the ids of the schemas that reference a version of a subject.  Version can either be a number
+//or 'latest'.
+func ReferencedBy(client HTTPClient, url string, subject Subject, version string) (ids []uint32, err error) {
+	var req *http.Request
+	req, err = ReferencedByRequest(url, subject, version)
+	if err == nil {
+		err = getByID(client, req, &ids)
+	}
+
+	return
+}
+
+//GetReferences returns the number of a version of a subject and the references of its schema.  Version can either be a
+//number or 'latest'.
+func GetReferences(client HTTPClient, url string, subject Subject, version string) (number int, references []Reference, err error) {
+	var req *http.Request
+	req, err = GetVersionRequest(url, subject, version)
+	if err == nil {
+		versionResponse := struct {
+			Version    int         `json:"version"`
+			References []Reference `json:"references"`
+		}{}
+
+		var status int
+		var body []byte
+		status, body, err = doJSON(client, req, &versionResponse)
+		if err == nil && status != http.StatusOK {
+			err = fmt.Errorf("%v:%s", status, body)
+		}
+
+		number, references = versionResponse.Version, versionResponse.References
+	}
+
+	return
+}
+
+//DependencyEdge is a reference of the From version to the To version, Name is the type referred to if it is known
+type DependencyEdge struct {
+	From SubjectVersion `json:"from"`
+	To   SubjectVersion `json:"to"`
+	Name string         `json:"name,omitempty"`
+}
+
+//DependencyGraph is the versions reachable from Root by following references, in the order they were found
+type DependencyGraph struct {
+	Root  SubjectVersion   `json:"root"`
+	Nodes []SubjectVersion `json:"nodes"`
+	Edges []DependencyEdge `json:"edges"`
+}
+
+//Dependencies returns the graph of the versions a version of a subject references, directly or through other
+//references.  If reverse it is the graph of the versions that reference it instead, which are found by looking up the
+//ids of the schemas that reference each version.  Edges always go from the referencing to the referenced version.
+func Dependencies(client HTTPClient, url string, subject Subject, version string, reverse bool) (graph DependencyGraph, err error) {
+	number, _, err := GetReferences(client, url, subject, version)
+	if err != nil {
+		return
+	}
+
+	graph.Root = SubjectVersion{Subject: subject, Version: number}
+	graph.Nodes = []SubjectVersion{graph.Root}
+	found := map[SubjectVersion]bool{graph.Root: true}
+
+	for next := 0; next < len(graph.Nodes); next++ {
+		node := graph.Nodes[next]
+
+		var edges []DependencyEdge
+		if reverse {
+			edges, err = referencedByEdges(client, url, node)
+		} else {
+			edges, err = referenceEdges(client, url, node)
+		}
+		if err != nil {
+			return
+		}
+
+		for _, edge := range edges {
+			graph.Edges = append(graph.Edges, edge)
+
+			other := edge.To
+			if reverse {
+				other = edge.From
+			}
+			if !found[other] {
+				found[other] = true
+				graph.Nodes = append(graph.Nodes, other)
+			}
+		}
+	}
+
+	return
+}
+
+func referenceEdges(client HTTPClient, url string, node SubjectVersion) ([]DependencyEdge, error) {
+	_, references, err := GetReferences(client, url, node.Subject, strconv.Itoa(node.Version))
+	if err != nil {
+		return nil, err
+	}
+
+	var edges []DependencyEdge
+	for _, reference := range references {
+		edges = append(edges, DependencyEdge{From: node, To: SubjectVersion{Subject: reference.Subject, Version: reference.Version}, Name: reference.Name})
+	}
+
+	return edges, nil
+}
+
+func referencedByEdges(client HTTPClient, url string, node SubjectVersion) ([]DependencyEdge, error) {
+	ids, err := ReferencedBy(client, url, node.Subject, strconv.Itoa(node.Version))
+	if err != nil {
+		return nil, err
+	}
+
+	var edges []DependencyEdge
+	for _, id := range ids {
+		versions, err := FindVersionsByID(client, url, id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, version := range versions {
+			edges = append(edges, DependencyEdge{From: version, To: node})
+		}
+	}
+
+	return edges, nil
+}
+
+//Dot returns the graph in the Graphviz DOT language, with the root in bold
+func (g DependencyGraph) Dot() string {
+	var b strings.Builder
+	b.WriteString("digraph references {\n")
+	fmt.Fprintf(&b, "\t%v [style=bold];\n", dotID(g.Root))
+	for _, node := range g.Nodes {
+		if node != g.Root {
+			fmt.Fprintf(&b, "\t%v;\n", dotID(node))
+		}
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "\t%v -> %v", dotID(edge.From), dotID(edge.To))
+		if edge.Name != "" {
+			fmt.Fprintf(&b, " [label=%v]", dotQuote(edge.Name))
+		}
+		b.WriteString(";\n")
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotID(node SubjectVersion) string {
+	return dotQuote(fmt.Sprintf("%v/%v", node.Subject, node.Version))
+}
+
+//dotQuote is s as a DOT quoted string, in which only quotes and backslashes are escaped
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,103 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//registerReferencing registers a schema with references, which Register does not send
+func registerReferencing(t *testing.T, ts *httptest.Server, subject Subject, schema Schema, references ...Reference) {
+	body, err := json.Marshal(map[string]interface{}{"schema": schema, "references": references})
+	require.NoError(t, err)
+
+	response, err := http.Post(ts.URL+"/subjects/"+string(subject)+"/versions", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestDependencies(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	address := Reference{Name: "com.mm.Address", Subject: "address", Version: 1}
+	user := Reference{Name: "com.mm.User", Subject: "user", Version: 1}
+
+	_, err := Register(tstClient(), ts.URL, "address", Schema(`{"type": "record", "name": "Address", "namespace": "com.mm", "fields": []}`))
+	require.NoError(t, err)
+	registerReferencing(t, ts, "user", Schema(`{"type": "record", "name": "User", "namespace": "com.mm", "fields": [{"name": "home", "type": "Address"}]}`), address)
+	registerReferencing(t, ts, "orders-value", Schema(`{"type": "record", "name": "Order", "namespace": "com.mm", "fields": [{"name": "buyer", "type": "User"}, {"name": "to", "type": "Address"}]}`), user, address)
+
+	number, references, err := GetReferences(tstClient(), ts.URL, "orders-value", Latest)
+	require.NoError(t, err)
+	assert.Equal(t, 1, number)
+	assert.Equal(t, []Reference{user, address}, references)
+
+	ids, err := ReferencedBy(tstClient(), ts.URL, "address", "1")
+	require.NoError(t, err)
+	assert.Len(t, ids, 2)
+
+	orders := SubjectVersion{Subject: "orders-value", Version: 1}
+	users := SubjectVersion{Subject: "user", Version: 1}
+	addresses := SubjectVersion{Subject: "address", Version: 1}
+
+	graph, err := Dependencies(tstClient(), ts.URL, "orders-value", Latest, false)
+	require.NoError(t, err)
+	assert.Equal(t, DependencyGraph{
+		Root:  orders,
+		Nodes: []SubjectVersion{orders, users, addresses},
+		Edges: []DependencyEdge{
+			{From: orders, To: users, Name: "com.mm.User"},
+			{From: orders, To: addresses, Name: "com.mm.Address"},
+			{From: users, To: addresses, Name: "com.mm.Address"},
+		},
+	}, graph)
+
+	assert.Equal(t, `digraph references {
+	"orders-value/1" [style=bold];
+	"user/1";
+	"address/1";
+	"orders-value/1" -> "user/1" [label="com.mm.User"];
+	"orders-value/1" -> "address/1" [label="com.mm.Address"];
+	"user/1" -> "address/1" [label="com.mm.Address"];
+}
+`, graph.Dot())
+
+	graph, err = Dependencies(tstClient(), ts.URL, "address", "1", true)
+	require.NoError(t, err)
+	assert.Equal(t, addresses, graph.Root)
+	assert.Equal(t, []SubjectVersion{addresses, users, orders}, graph.Nodes)
+	assert.ElementsMatch(t, []DependencyEdge{
+		{From: users, To: addresses},
+		{From: orders, To: addresses},
+		{From: orders, To: users},
+	}, graph.Edges)
+
+	_, err = Dependencies(tstClient(), ts.URL, "missing", Latest, false)
+	assert.Error(t, err)
+}
+
+func TestDotQuotesForDot(t *testing.T) {
+	graph := DependencyGraph{
+		Root:  SubjectVersion{Subject: "café-value", Version: 1},
+		Nodes: []SubjectVersion{{Subject: "café-value", Version: 1}, {Subject: `a"b\c`, Version: 2}},
+		Edges: []DependencyEdge{{From: SubjectVersion{Subject: "café-value", Version: 1}, To: SubjectVersion{Subject: `a"b\c`, Version: 2}, Name: "com.mm.Café"}},
+	}
+
+	assert.Equal(t, `digraph references {
+	"café-value/1" [style=bold];
+	"a\"b\\c/2";
+	"café-value/1" -> "a\"b\\c/2" [label="com.mm.Café"];
+}
+`, graph.Dot())
+}
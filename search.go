@@ -0,0 +1,349 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MediaMath/sr/avro"
+)
+
+//SchemaSource is where Search finds the versions of subjects and their schemas
+type SchemaSource interface {
+	//Subjects returns every subject of the source
+	Subjects() ([]Subject, error)
+	//Versions returns the versions of a subject, oldest first
+	Versions(subject Subject) ([]int, error)
+	//Schema returns the schema of a version of a subject
+	Schema(subject Subject, version int) (Schema, error)
+}
+
+//RegistrySource is the SchemaSource of the registry at URL
+type RegistrySource struct {
+	Client HTTPClient
+	URL    string
+}
+
+//Subjects lists the subjects of the registry
+func (r RegistrySource) Subjects() ([]Subject, error) {
+	return ListSubjects(r.Client, r.URL)
+}
+
+//Versions lists the versions of the subject
+func (r RegistrySource) Versions(subject Subject) ([]int, error) {
+	return ListVersions(r.Client, r.URL, subject)
+}
+
+//Schema gets the schema of the version of the subject
+func (r RegistrySource) Schema(subject Subject, version int) (Schema, error) {
+	_, schema, err := GetVersion(r.Client, r.URL, subject, strconv.Itoa(version))
+	return schema, err
+}
+
+//DirectorySource is the SchemaSource of a directory of exported schemas, laid out as
+//
+//	<directory>/<subject>/<version>.avsc
+//
+//There is a directory per subject, named after the subject escaped with url.PathEscape so subjects like clicks/value
+//are one directory, clicks%2Fvalue.  In it is a file per version, like 3.avsc, holding the schema of that version as the
+//registry returns it.  Other files and directories are ignored.
+type DirectorySource string
+
+//Subjects returns the subjects of the directories in the export directory
+func (d DirectorySource) Subjects() ([]Subject, error) {
+	entries, err := ioutil.ReadDir(string(d))
+	if err != nil {
+		return nil, err
+	}
+
+	var subjects []Subject
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		subject, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%v is not a path escaped subject: %v", entry.Name(), err)
+		}
+		subjects = append(subjects, Subject(subject))
+	}
+
+	return subjects, nil
+}
+
+//Versions returns the versions of the .avsc files of the subject
+func (d DirectorySource) Versions(subject Subject) ([]int, error) {
+	entries, err := ioutil.ReadDir(d.dir(subject))
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+	for _, entry := range entries {
+		if version, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".avsc")); err == nil && strings.HasSuffix(entry.Name(), ".avsc") {
+			versions = append(versions, version)
+		}
+	}
+	sort.Ints(versions)
+
+	return versions, nil
+}
+
+//Schema reads the .avsc file of the version of the subject
+func (d DirectorySource) Schema(subject Subject, version int) (Schema, error) {
+	schema, err := ioutil.ReadFile(filepath.Join(d.dir(subject), fmt.Sprintf("%v.avsc", version)))
+	return Schema(schema), err
+}
+
+func (d DirectorySource) dir(subject Subject) string {
+	return filepath.Join(string(d), url.PathEscape(string(subject)))
+}
+
+//SearchQuery is what Search looks for.  A named type or field is a hit if it matches every criterion that is set.
+type SearchQuery struct {
+	//Record is the name or full name of the record, enum or fixed, or of the record a field is in
+	Record string
+	//Field is the name of a field, only fields match if it is set
+	Field string
+	//Type is the type of a field or named type, like long, com.mm.Address, record, array or a logical type like uuid.
+	//A union matches the types of its branches.
+	Type string
+	//Namespace is the namespace of the named type or of the record a field is in
+	Namespace string
+	//Doc is a case insensitive part of the doc
+	Doc string
+	//Pattern matches the name, path, doc or enum symbols
+	Pattern *regexp.Regexp
+	//AllVersions searches every version of every subject rather than just the latest
+	AllVersions bool
+}
+
+func (q SearchQuery) empty() bool {
+	return q.Record == "" && q.Field == "" && q.Type == "" && q.Namespace == "" && q.Doc == "" && q.Pattern == nil
+}
+
+//SearchHit is a named type or field of a version of a subject that matched.  Path is the full name of the named type
+//or the path of the field from the full name of the record it is in, like com.mm.User.address.street.
+type SearchHit struct {
+	Subject Subject `json:"subject"`
+	Version int     `json:"version"`
+	Path    string  `json:"path"`
+	Type    string  `json:"type"`
+}
+
+//SearchSkip is a subject or version Search could not search and why.  Version is 0 if the versions of the subject could
+//not be read.
+type SearchSkip struct {
+	Subject Subject
+	Version int
+	Err     error
+}
+
+//Search returns the hits of the query in the schemas of source, by subject and version.  Subjects whose versions cannot
+//be read and versions whose schemas cannot be read or do not parse, for instance because they use schema references,
+//are returned as skipped rather than failing the search.
+func Search(source SchemaSource, query SearchQuery) (hits []SearchHit, skipped []SearchSkip, err error) {
+	if query.empty() {
+		return nil, nil, fmt.Errorf("nothing to search for")
+	}
+
+	subjects, err := source.Subjects()
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(subjects, func(i, j int) bool { return subjects[i] < subjects[j] })
+
+	for _, subject := range subjects {
+		versions, err := source.Versions(subject)
+		if err != nil {
+			skipped = append(skipped, SearchSkip{Subject: subject, Err: err})
+			continue
+		}
+
+		if !query.AllVersions && len(versions) > 1 {
+			versions = versions[len(versions)-1:]
+		}
+
+		for _, version := range versions {
+			schema, err := source.Schema(subject, version)
+			if err != nil {
+				skipped = append(skipped, SearchSkip{Subject: subject, Version: version, Err: err})
+				continue
+			}
+
+			parsed, err := avro.Parse(string(schema))
+			if err != nil {
+				skipped = append(skipped, SearchSkip{Subject: subject, Version: version, Err: err})
+				continue
+			}
+
+			for _, e := range searchElements(parsed) {
+				if e.matches(query) {
+					hits = append(hits, SearchHit{Subject: subject, Version: version, Path: e.path, Type: e.types[0]})
+				}
+			}
+		}
+	}
+
+	return hits, skipped, nil
+}
+
+//searchElement is a named type or field a query is matched against
+type searchElement struct {
+	path       string
+	name       string
+	record     string
+	recordName string
+	namespace  string
+	doc        string
+	types      []string
+	symbols    []string
+	field      bool
+}
+
+func (e searchElement) matches(q SearchQuery) bool {
+	switch {
+	case q.Field != "" && (!e.field || e.name != q.Field):
+		return false
+	case q.Record != "" && e.record != q.Record && e.recordName != q.Record:
+		return false
+	case q.Namespace != "" && e.namespace != q.Namespace:
+		return false
+	case q.Doc != "" && !strings.Contains(strings.ToLower(e.doc), strings.ToLower(q.Doc)):
+		return false
+	case q.Type != "" && !containsString(e.types, q.Type):
+		return false
+	case q.Pattern != nil && !e.matchesPattern(q.Pattern):
+		return false
+	}
+
+	return true
+}
+
+func (e searchElement) matchesPattern(pattern *regexp.Regexp) bool {
+	for _, text := range append([]string{e.name, e.path, e.doc}, e.symbols...) {
+		if text != "" && pattern.MatchString(text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//searchElements returns the named types and fields of schema, named types are only walked where they are defined
+func searchElements(schema avro.Schema) []searchElement {
+	var elements []searchElement
+	walked := make(map[string]bool)
+
+	var walk func(s avro.Schema, path string)
+	walk = func(s avro.Schema, path string) {
+		switch s := s.(type) {
+		case *avro.UnionSchema:
+			for _, branch := range s.Types {
+				walk(branch, path)
+			}
+		case *avro.ArraySchema:
+			walk(s.Items, path)
+		case *avro.MapSchema:
+			walk(s.Values, path)
+		case avro.NamedSchema:
+			name := s.FullName()
+			if walked[name] {
+				return
+			}
+			walked[name] = true
+
+			namespace, unqualified := avro.SplitFullName(name)
+			element := searchElement{path: name, name: unqualified, record: name, recordName: unqualified, namespace: namespace, types: typeNames(s)}
+			switch s := s.(type) {
+			case *avro.RecordSchema:
+				element.doc = s.Doc
+			case *avro.EnumSchema:
+				element.doc, element.symbols = s.Doc, s.Symbols
+			case *avro.FixedSchema:
+				element.doc = s.Doc
+			}
+			elements = append(elements, element)
+
+			record, ok := s.(*avro.RecordSchema)
+			if !ok {
+				return
+			}
+
+			//fields of nested records continue the path of the field they are in
+			if path == "" {
+				path = name
+			}
+			for _, field := range record.Fields {
+				fieldPath := avro.JoinPath(path, field.Name)
+				elements = append(elements, searchElement{
+					path:       fieldPath,
+					name:       field.Name,
+					record:     name,
+					recordName: unqualified,
+					namespace:  namespace,
+					doc:        field.Doc,
+					types:      typeNames(field.Type),
+					field:      true,
+				})
+				walk(field.Type, fieldPath)
+			}
+		}
+	}
+	walk(schema, "")
+
+	return elements
+}
+
+//typeNames are the names a query Type matches s by, the first is the one a hit shows
+func typeNames(s avro.Schema) []string {
+	switch s := s.(type) {
+	case *avro.PrimitiveSchema:
+		if s.Logical != nil {
+			return []string{s.Logical.Name, string(s.Primitive)}
+		}
+		return []string{string(s.Primitive)}
+	case *avro.UnionSchema:
+		var names []string
+		for _, branch := range s.Types {
+			names = append(names, typeNames(branch)[0])
+		}
+		names = []string{"[" + strings.Join(names, ",") + "]"}
+		for _, branch := range s.Types {
+			names = append(names, typeNames(branch)...)
+		}
+		return names
+	case *avro.FixedSchema:
+		_, name := avro.SplitFullName(s.FullName())
+		names := []string{s.FullName(), name, string(avro.Fixed)}
+		if s.Logical != nil {
+			names = append(names, s.Logical.Name)
+		}
+		return names
+	case avro.NamedSchema:
+		_, name := avro.SplitFullName(s.FullName())
+		return []string{s.FullName(), name, string(s.Type())}
+	}
+
+	return []string{avro.TypeName(s)}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,146 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	clicksV1 = Schema(`{"type": "record", "name": "Click", "namespace": "com.mm", "fields": [
+		{"name": "campaign_id", "type": "int"}
+	]}`)
+	clicksV2 = Schema(`{"type": "record", "name": "Click", "namespace": "com.mm", "fields": [
+		{"name": "campaign_id", "type": "long", "doc": "The Campaign clicked"},
+		{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "where", "type": ["null", {"type": "record", "name": "Site", "fields": [
+			{"name": "campaign_id", "type": ["null", "long"]},
+			{"name": "kind", "type": {"type": "enum", "name": "Kind", "namespace": "com.other", "symbols": ["WEB", "APP"]}}
+		]}]}
+	]}`)
+	bidsV1 = Schema(`{"type": "record", "name": "Bid", "namespace": "com.mm", "fields": [
+		{"name": "campaign_id", "type": "string"},
+		{"name": "site", "type": ["null", "com.mm.Site"]}
+	]}`)
+)
+
+func searchSources(t *testing.T) (map[string]SchemaSource, func()) {
+	ts := memoryRegistry()
+
+	dir, err := ioutil.TempDir("", "sr-search")
+	require.NoError(t, err)
+
+	export := func(subject Subject, version int, schema Schema) {
+		_, err := Register(tstClient(), ts.URL, subject, schema)
+		require.NoError(t, err)
+
+		subjectDir := DirectorySource(dir).dir(subject)
+		require.NoError(t, os.MkdirAll(subjectDir, 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(subjectDir, fmt.Sprintf("%v.avsc", version)), []byte(schema), 0644))
+	}
+	export("clicks/value", 1, clicksV1)
+	export("clicks/value", 2, clicksV2)
+	export("bids-value", 1, bidsV1)
+
+	return map[string]SchemaSource{"registry": RegistrySource{Client: tstClient(), URL: ts.URL}, "directory": DirectorySource(dir)}, func() {
+		ts.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	sources, cleanup := searchSources(t)
+	defer cleanup()
+
+	for name, source := range sources {
+		hits, skipped, err := Search(source, SearchQuery{Field: "campaign_id", Type: "long"})
+		require.NoError(t, err, name)
+		require.Len(t, skipped, 1, name)
+		assert.Equal(t, Subject("bids-value"), skipped[0].Subject, name)
+		assert.Equal(t, 1, skipped[0].Version, name)
+		assert.Error(t, skipped[0].Err, "%v: bids refers to a type it does not define", name)
+		assert.Equal(t, []SearchHit{
+			{Subject: "clicks/value", Version: 2, Path: "com.mm.Click.campaign_id", Type: "long"},
+			{Subject: "clicks/value", Version: 2, Path: "com.mm.Click.where.campaign_id", Type: "[null,long]"},
+		}, hits, name)
+
+		hits, _, err = Search(source, SearchQuery{Field: "campaign_id", AllVersions: true})
+		require.NoError(t, err, name)
+		assert.Len(t, hits, 3, name)
+		assert.Equal(t, SearchHit{Subject: "clicks/value", Version: 1, Path: "com.mm.Click.campaign_id", Type: "int"}, hits[0], name)
+
+		hits, _, err = Search(source, SearchQuery{Namespace: "com.other"})
+		require.NoError(t, err, name)
+		assert.Equal(t, []SearchHit{{Subject: "clicks/value", Version: 2, Path: "com.other.Kind", Type: "com.other.Kind"}}, hits, name)
+
+		hits, _, err = Search(source, SearchQuery{Record: "Site", Type: "record"})
+		require.NoError(t, err, name)
+		assert.Equal(t, []SearchHit{{Subject: "clicks/value", Version: 2, Path: "com.mm.Site", Type: "com.mm.Site"}}, hits, name)
+
+		hits, _, err = Search(source, SearchQuery{Doc: "campaign CLICKED"})
+		require.NoError(t, err, name)
+		assert.Equal(t, []SearchHit{{Subject: "clicks/value", Version: 2, Path: "com.mm.Click.campaign_id", Type: "long"}}, hits, name)
+
+		hits, _, err = Search(source, SearchQuery{Type: "timestamp-millis"})
+		require.NoError(t, err, name)
+		assert.Equal(t, []SearchHit{{Subject: "clicks/value", Version: 2, Path: "com.mm.Click.at", Type: "timestamp-millis"}}, hits, name)
+
+		hits, _, err = Search(source, SearchQuery{Pattern: regexp.MustCompile("^AP")})
+		require.NoError(t, err, name)
+		assert.Equal(t, []SearchHit{{Subject: "clicks/value", Version: 2, Path: "com.other.Kind", Type: "com.other.Kind"}}, hits, name, "enum symbols")
+
+		_, _, err = Search(source, SearchQuery{AllVersions: true})
+		assert.Error(t, err, name)
+	}
+}
+
+//failingSource fails to read the versions of versionless and the schemas of unreadable
+type failingSource struct {
+	SchemaSource
+	versionless, unreadable Subject
+}
+
+func (f failingSource) Versions(subject Subject) ([]int, error) {
+	if subject == f.versionless {
+		return nil, fmt.Errorf("no versions for %v", subject)
+	}
+
+	return f.SchemaSource.Versions(subject)
+}
+
+func (f failingSource) Schema(subject Subject, version int) (Schema, error) {
+	if subject == f.unreadable {
+		return EmptySchema, fmt.Errorf("no schema for %v", subject)
+	}
+
+	return f.SchemaSource.Schema(subject, version)
+}
+
+func TestSearchSkipsFailures(t *testing.T) {
+	sources, cleanup := searchSources(t)
+	defer cleanup()
+
+	for name, source := range sources {
+		hits, skipped, err := Search(failingSource{SchemaSource: source, versionless: "bids-value"}, SearchQuery{Field: "campaign_id"})
+		require.NoError(t, err, name)
+		assert.Equal(t, []SearchSkip{{Subject: "bids-value", Err: fmt.Errorf("no versions for bids-value")}}, skipped, name)
+		assert.Len(t, hits, 2, name)
+
+		hits, skipped, err = Search(failingSource{SchemaSource: source, unreadable: "clicks/value"}, SearchQuery{Field: "campaign_id", AllVersions: true})
+		require.NoError(t, err, name)
+		require.Len(t, skipped, 3, name)
+		assert.Equal(t, SearchSkip{Subject: "clicks/value", Version: 1, Err: fmt.Errorf("no schema for clicks/value")}, skipped[1], name)
+		assert.Equal(t, SearchSkip{Subject: "clicks/value", Version: 2, Err: fmt.Errorf("no schema for clicks/value")}, skipped[2], name)
+		assert.Empty(t, hits, name)
+	}
+}
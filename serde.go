@@ -0,0 +1,176 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/MediaMath/sr/avro"
+)
+
+//SchemaOf returns the avro schema of the Go type of v, so types that own the shape of their data can be registered and
+//checked for compatibility.  See avro.SchemaOf for how types and their `avro` tags map to the schema.
+func SchemaOf(v interface{}) (Schema, error) {
+	parsed, err := avro.SchemaOf(v)
+	if err != nil {
+		return EmptySchema, err
+	}
+
+	return Schema(avro.Format(parsed)), nil
+}
+
+//Serializer encodes values as avro in the Confluent wire format: the schema id of the subject for the topic followed
+//by the avro binary encoding.  It is safe for concurrent use and does not depend on any kafka client.
+type Serializer struct {
+	//Strategy derives the subject from the topic and the schema, TopicNameStrategy if it is nil
+	Strategy SubjectNameStrategy
+	//Key is whether the values are the keys of the topic rather than its values
+	Key bool
+	//Context qualifies the subject the strategy gives, the DefaultContext if it is empty
+	Context Context
+	//AutoRegister registers the schema on the subject if it is not there already.  Otherwise the schema must already be
+	//registered.
+	AutoRegister bool
+
+	cache  *Cache
+	schema Schema
+	parsed avro.Schema
+}
+
+//NewSerializer returns a Serializer for values of schema that looks schema ids up through cache
+func NewSerializer(cache *Cache, schema Schema) (*Serializer, error) {
+	parsed, err := avro.Parse(string(schema))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Serializer{cache: cache, schema: schema, parsed: parsed}, nil
+}
+
+//Serialize returns v, framed with the id of the schema on the subject the strategy gives for topic.  See avro.Marshal for the values v can be.
+func (s *Serializer) Serialize(topic string, v interface{}) ([]byte, error) {
+	strategy := s.Strategy
+	if strategy == nil {
+		strategy = TopicNameStrategy{}
+	}
+
+	subject, err := strategy.Subject(topic, s.Key, s.parsed)
+	if err != nil {
+		return nil, err
+	}
+	subject = s.Context.Qualify(subject)
+
+	id, err := s.id(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return avro.AppendBinary(Frame(id, nil), s.parsed, v)
+}
+
+func (s *Serializer) id(subject Subject) (uint32, error) {
+	if s.AutoRegister {
+		return s.cache.Register(subject, s.schema)
+	}
+
+	//HasSchema reports a schema that is not on the subject as id 0
+	_, id, err := s.cache.HasSchema(subject, s.schema)
+	if err == nil && id == 0 {
+		err = fmt.Errorf("schema is not registered on %v", subject)
+	}
+
+	return uint32(id), err
+}
+
+//Deserializer decodes payloads in the Confluent wire format, fetching the schema they were written with by id and
+//resolving it to a reader schema.  It is safe for concurrent use and does not depend on any kafka client.
+type Deserializer struct {
+	cache  *Cache
+	reader avro.Schema
+
+	//writers are the parsed schemas by id
+	writers sync.Map
+}
+
+//NewDeserializer returns a Deserializer that looks schemas up through cache and decodes into reader.  If reader is
+//EmptySchema values are decoded as the schema they were written with.
+func NewDeserializer(cache *Cache, reader Schema) (*Deserializer, error) {
+	d := &Deserializer{cache: cache}
+
+	if reader != EmptySchema {
+		parsed, err := avro.Parse(string(reader))
+		if err != nil {
+			return nil, err
+		}
+		d.reader = parsed
+	}
+
+	return d, nil
+}
+
+//Deserialize decodes a framed payload into the value v points to.  See avro.Unmarshal for the values v can point to.
+func (d *Deserializer) Deserialize(data []byte, v interface{}) error {
+	id, payload, err := Unframe(data)
+	if err != nil {
+		return err
+	}
+
+	writer, err := d.WriterSchema(id)
+	if err != nil {
+		return err
+	}
+
+	reader := d.reader
+	if reader == nil {
+		reader = writer
+	}
+
+	if err := avro.UnmarshalResolved(writer, reader, payload, v); err != nil {
+		return fmt.Errorf("schema %v: %v", id, err)
+	}
+
+	return nil
+}
+
+//ToJSON converts a framed payload to the avro JSON encoding of its value in the schema it was written with
+func (d *Deserializer) ToJSON(data []byte) ([]byte, error) {
+	id, payload, err := Unframe(data)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := d.WriterSchema(id)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := avro.BinaryToJSON(writer, payload)
+	if err != nil {
+		return nil, fmt.Errorf("schema %v: %v", id, err)
+	}
+
+	return encoded, nil
+}
+
+//WriterSchema returns the parsed schema for an id
+func (d *Deserializer) WriterSchema(id uint32) (avro.Schema, error) {
+	if parsed, ok := d.writers.Load(id); ok {
+		return parsed.(avro.Schema), nil
+	}
+
+	schema, err := d.cache.GetSchema(id)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := avro.Parse(string(schema))
+	if err != nil {
+		return nil, fmt.Errorf("schema %v: %v", id, err)
+	}
+
+	d.writers.Store(id, parsed)
+	return parsed, nil
+}
@@ -0,0 +1,154 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serdeUser struct {
+	Name  string `avro:"name"`
+	Email *string
+}
+
+var (
+	userV1 = Schema(`{"type": "record", "name": "User", "fields": [{"name": "name", "type": "string"}, {"name": "email", "type": ["null", "string"], "default": null}]}`)
+	userV2 = Schema(`{"type": "record", "name": "User", "fields": [{"name": "name", "type": "string"}, {"name": "age", "type": "int", "default": -1}]}`)
+)
+
+func TestSerializeDeserialize(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	cache := NewCache(tstClient(), ts.URL, 10)
+
+	serializer, err := NewSerializer(cache, userV1)
+	require.NoError(t, err)
+
+	_, err = serializer.Serialize("users", serdeUser{Name: "ann"})
+	assert.Error(t, err, "the schema is not registered")
+
+	serializer.AutoRegister = true
+	email := "ann@example.com"
+	data, err := serializer.Serialize("users", serdeUser{Name: "ann", Email: &email})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0, 1}, data[:HeaderSize])
+
+	serializer.AutoRegister = false
+	again, err := serializer.Serialize("users", &serdeUser{Name: "ann", Email: &email})
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	deserializer, err := NewDeserializer(cache, EmptySchema)
+	require.NoError(t, err)
+
+	var user serdeUser
+	require.NoError(t, deserializer.Deserialize(data, &user))
+	assert.Equal(t, serdeUser{Name: "ann", Email: &email}, user)
+
+	encoded, err := deserializer.ToJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"ann","email":{"string":"ann@example.com"}}`, string(encoded))
+
+	resolving, err := NewDeserializer(NewCache(tstClient(), ts.URL, 10), userV2)
+	require.NoError(t, err)
+
+	var generic interface{}
+	require.NoError(t, resolving.Deserialize(data, &generic))
+	assert.Equal(t, map[string]interface{}{"name": "ann", "age": int32(-1)}, generic)
+}
+
+type reflectedUser struct {
+	_    struct{} `avro:"User"`
+	Name string   `avro:"name"`
+	Age  int32    `avro:"age,default=-1"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema, err := SchemaOf(reflectedUser{})
+	require.NoError(t, err)
+	assert.Equal(t, Schema(`{"type":"record","name":"User","fields":[{"name":"name","type":"string"},{"name":"age","type":"int","default":-1}]}`), schema)
+
+	incompatibilities, err := CheckCompatibility(Backward, schema, []Schema{userV1})
+	require.NoError(t, err)
+	assert.Empty(t, incompatibilities)
+
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	serializer, err := NewSerializer(NewCache(tstClient(), ts.URL, 10), schema)
+	require.NoError(t, err)
+	serializer.AutoRegister = true
+
+	_, err = serializer.Serialize("users", reflectedUser{Name: "ann", Age: 30})
+	require.NoError(t, err)
+
+	_, err = SchemaOf(struct{}{})
+	assert.Error(t, err)
+}
+
+func TestSerializeKeys(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	cache := NewCache(tstClient(), ts.URL, 10)
+	serializer, err := NewSerializer(cache, Schema(`"long"`))
+	require.NoError(t, err)
+	serializer.AutoRegister = true
+	serializer.Key = true
+
+	data, err := serializer.Serialize("users", 5)
+	require.NoError(t, err)
+
+	_, id, err := cache.HasSchema(KeySubject("users"), Schema(`"long"`))
+	require.NoError(t, err)
+	assert.Equal(t, Frame(uint32(id), []byte{10}), data)
+
+	_, err = serializer.Serialize("", 5)
+	assert.Error(t, err)
+
+	_, err = serializer.Serialize("users", "five")
+	assert.Error(t, err)
+
+	serializer, err = NewSerializer(cache, userV1)
+	require.NoError(t, err)
+	serializer.AutoRegister = true
+	serializer.Strategy = TopicRecordNameStrategy{}
+
+	data, err = serializer.Serialize("users", serdeUser{Name: "ann"})
+	require.NoError(t, err)
+
+	_, id, err = cache.HasSchema(Subject("users-User"), userV1)
+	require.NoError(t, err)
+	assert.Equal(t, Frame(uint32(id), nil), data[:HeaderSize])
+
+	serializer.Context = "tenant"
+	_, err = serializer.Serialize("users", serdeUser{Name: "ann"})
+	require.NoError(t, err)
+
+	_, _, err = cache.HasSchema(Subject(":.tenant:users-User"), userV1)
+	assert.NoError(t, err)
+}
+
+func TestDeserializeErrors(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	deserializer, err := NewDeserializer(NewCache(tstClient(), ts.URL, 10), EmptySchema)
+	require.NoError(t, err)
+
+	var v interface{}
+	assert.Error(t, deserializer.Deserialize([]byte{1, 0, 0, 0, 1}, &v))
+	assert.Error(t, deserializer.Deserialize(Frame(9, nil), &v))
+
+	_, err = NewSerializer(nil, Schema(`{`))
+	assert.Error(t, err)
+
+	_, err = NewDeserializer(nil, Schema(`"nope"`))
+	assert.Error(t, err)
+}
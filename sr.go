@@ -7,12 +7,12 @@ package sr
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"path"
 	"strings"
 )
 
@@ -39,7 +39,7 @@ type ConfigPutJSON struct {
 
 //GetLatestSchema returns the latest schema and id for a subject
 func GetLatestSchema(client HTTPClient, url string, subject Subject) (id uint32, schema Schema, err error) {
-	return GetVersion(client, url, subject, "latest")
+	return GetVersion(client, url, subject, Latest)
 }
 
 //GetVersion returns a schema and id for a subject and version
@@ -77,17 +77,24 @@ func GetSchema(client HTTPClient, url string, id uint32) (schema Schema, err err
 	schema = EmptySchema
 
 	var req *http.Request
+	var status int
+	var body []byte
+
 	req, err = GetSchemaRequest(url, id)
 	if err == nil {
 
 		schemaResponse := &SchemaJSON{}
-		_, _, err = doJSON(client, req, &schemaResponse)
+		status, body, err = doJSON(client, req, &schemaResponse)
 
 		if err == nil {
 			schema = schemaResponse.Schema
 		}
 	}
 
+	if status >= http.StatusInternalServerError {
+		err = &UnavailableError{Status: status, Err: fmt.Errorf("%v:%s", status, body)}
+	}
+
 	return
 }
 
@@ -144,6 +151,14 @@ func HasSchema(client HTTPClient, url string, subject Subject, schema Schema) (v
 	return
 }
 
+//CompatibilityResult is the outcome of a compatibility check and, when the schema is not compatible, the reasons why
+type CompatibilityResult struct {
+	IsCompatible bool     `json:"is_compatible"`
+	Messages     []string `json:"messages,omitempty"`
+	//Local is set if the registry gave no reasons and Messages were computed by CheckCompatibility instead
+	Local bool `json:"-"`
+}
+
 //IsCompatible will return if the provided schema is compatible with the subject and version provided. Version can either be a numeric version or 'latest'
 func IsCompatible(client HTTPClient, url string, subject Subject, version string, schema Schema) (is bool, err error) {
 	var req *http.Request
@@ -169,11 +184,125 @@ func IsCompatible(client HTTPClient, url string, subject Subject, version string
 	return
 }
 
+//CheckIsCompatible is IsCompatible that also returns the reasons a schema is not compatible.  The registry is asked for
+//verbose messages, if it does not provide any they are computed locally against the version's schema.
+func CheckIsCompatible(client HTTPClient, url string, subject Subject, version string, schema Schema) (result CompatibilityResult, err error) {
+	var req *http.Request
+	body := &SchemaJSON{schema}
+	req, err = CheckIsCompatibleVerboseRequest(url, subject, version, body)
+	if err == nil {
+		var status int
+		var body []byte
+		status, body, err = doJSON(client, req, &result)
+		if status != 200 {
+			err = fmt.Errorf("Unexpected return code: %v:%s", status, body)
+		}
+	}
+
+	if err == nil && !result.IsCompatible && len(result.Messages) == 0 {
+		result.Messages = explainLocally(client, url, subject, version, schema)
+		result.Local = len(result.Messages) > 0
+	}
+
+	return
+}
+
+//explainLocally is best effort, any failure just means there is no explanation
+func explainLocally(client HTTPClient, url string, subject Subject, version string, schema Schema) []string {
+	level, err := GetSubjectDerivedCompatibility(client, url, subject)
+	if err != nil {
+		return nil
+	}
+
+	//the registry checks a single version here, whatever the transitivity of the level.  BACKWARD is its default level.
+	switch level {
+	case BackwardTransitive:
+		level = Backward
+	case ForwardTransitive:
+		level = Forward
+	case FullTransitive:
+		level = Full
+	case Zero:
+		level = Backward
+	}
+
+	_, previous, err := GetVersion(client, url, subject, version)
+	if err != nil {
+		return nil
+	}
+
+	incompatibilities, err := CheckCompatibility(level, schema, []Schema{previous})
+	if err != nil {
+		return nil
+	}
+
+	var messages []string
+	for _, incompatibility := range incompatibilities {
+		if incompatibility.Backward {
+			messages = append(messages, fmt.Sprintf("cannot read data written with version %v: %v", version, incompatibility.Incompatibility))
+		} else {
+			messages = append(messages, fmt.Sprintf("version %v cannot read data written with this schema: %v", version, incompatibility.Incompatibility))
+		}
+	}
+
+	return messages
+}
+
+//ListSubjectsOptions filters the subjects ListSubjectsWithOptions returns
+type ListSubjectsOptions struct {
+	//Deleted includes soft deleted subjects
+	Deleted bool
+	//DeletedOnly returns only soft deleted subjects
+	DeletedOnly bool
+	//SubjectPrefix has the registry return only the subjects that start with it
+	SubjectPrefix string
+}
+
+func (o ListSubjectsOptions) query() url.Values {
+	query := deletedQuery(o.Deleted, o.DeletedOnly)
+	if o.SubjectPrefix != "" {
+		query.Set("subjectPrefix", o.SubjectPrefix)
+	}
+
+	return query
+}
+
+//ListVersionsOptions filters the versions ListVersionsWithOptions returns
+type ListVersionsOptions struct {
+	//Deleted includes soft deleted versions
+	Deleted bool
+	//DeletedOnly returns only soft deleted versions
+	DeletedOnly bool
+}
+
+func (o ListVersionsOptions) query() url.Values {
+	return deletedQuery(o.Deleted, o.DeletedOnly)
+}
+
+func deletedQuery(deleted, deletedOnly bool) url.Values {
+	query := url.Values{}
+	if deleted {
+		query.Set("deleted", "true")
+	}
+
+	if deletedOnly {
+		query.Set("deletedOnly", "true")
+	}
+
+	return query
+}
+
 //ListSubjects returns the list of subjects
 func ListSubjects(client HTTPClient, url string) (subjects []Subject, err error) {
+	return ListSubjectsWithOptions(client, url, ListSubjectsOptions{})
+}
+
+//ListSubjectsWithOptions returns the list of subjects the options filter
+func ListSubjectsWithOptions(client HTTPClient, url string, options ListSubjectsOptions) (subjects []Subject, err error) {
 	var req *http.Request
 	req, err = ListSubjectsRequest(url)
 	if err == nil {
+		addQuery(req, options.query())
 		_, _, err = doJSON(client, req, &subjects)
 	}
 
@@ -182,9 +311,15 @@ func ListSubjects(client HTTPClient, url string) (subjects []Subject, err error)
 
 //ListVersions returns the list of versions for a subject
 func ListVersions(client HTTPClient, url string, subject Subject) (versions []int, err error) {
+	return ListVersionsWithOptions(client, url, subject, ListVersionsOptions{})
+}
+
+//ListVersionsWithOptions returns the list of versions for a subject the options filter
+func ListVersionsWithOptions(client HTTPClient, url string, subject Subject, options ListVersionsOptions) (versions []int, err error) {
 	var req *http.Request
 	req, err = ListVersionsRequest(url, subject)
 	if err == nil {
+		addQuery(req, options.query())
 		_, _, err = doJSON(client, req, &versions)
 	}
 
@@ -279,57 +414,105 @@ func compatibilityJSON(client HTTPClient, req *http.Request) (status int, compat
 
 //GetSchemaRequest returns the http.Request for GET /schemas/ids/<id> route
 func GetSchemaRequest(baseURL string, id uint32) (*http.Request, error) {
-	return get(baseURL, path.Join("schemas", "ids", fmt.Sprintf("%v", id)))
+	return get(baseURL, endpoint("schemas", "ids", fmt.Sprintf("%v", id)), Call{Operation: OpGetSchema, ID: id})
 }
 
 //RegisterRequest returns the http.Request for the POST  /subjects/<subject>/versions
 func RegisterRequest(baseURL string, subject Subject, body *SchemaJSON) (*http.Request, error) {
-	return post(baseURL, path.Join("subjects", string(subject), "versions"), body)
+	if err := subject.Validate(); err != nil {
+		return nil, err
+	}
+
+	return post(baseURL, endpoint("subjects", string(subject), "versions"), body, Call{Operation: OpRegister, Subject: subject})
 }
 
 //GetVersionRequest returns the http.Request for the GET /subjects/<subject>/versions/<version> version can either be a number or 'latest'
 func GetVersionRequest(baseURL string, subject Subject, version string) (*http.Request, error) {
-	return get(baseURL, path.Join("subjects", string(subject), "versions", version))
+	if err := subject.Validate(); err != nil {
+		return nil, err
+	}
+
+	return get(baseURL, endpoint("subjects", string(subject), "versions", version), Call{Operation: OpGetVersion, Subject: subject, Version: version})
 }
 
 //HasSchemaRequest returns the http.Request for the POST /subjects/<subject>
 func HasSchemaRequest(baseURL string, subject Subject, body *SchemaJSON) (*http.Request, error) {
-	return post(baseURL, path.Join("subjects", string(subject)), body)
+	if err := subject.Validate(); err != nil {
+		return nil, err
+	}
+
+	return post(baseURL, endpoint("subjects", string(subject)), body, Call{Operation: OpHasSchema, Subject: subject})
 }
 
 //CheckIsCompatibleRequest returns the http.Request for the POST /compatibility/subjects/<subject>/versions/<version> route
 func CheckIsCompatibleRequest(baseURL string, subject Subject, version string, body *SchemaJSON) (*http.Request, error) {
-	return post(baseURL, path.Join("compatibility", "subjects", string(subject), "versions", version), body)
+	if err := subject.Validate(); err != nil {
+		return nil, err
+	}
+
+	return post(baseURL, endpoint("compatibility", "subjects", string(subject), "versions", version), body, Call{Operation: OpIsCompatible, Subject: subject, Version: version})
+}
+
+//CheckIsCompatibleVerboseRequest is CheckIsCompatibleRequest with ?verbose=true, for which the registry also answers
+//with the reasons a schema is not compatible
+func CheckIsCompatibleVerboseRequest(baseURL string, subject Subject, version string, body *SchemaJSON) (*http.Request, error) {
+	request, err := CheckIsCompatibleRequest(baseURL, subject, version, body)
+	if request != nil {
+		addQuery(request, url.Values{"verbose": []string{"true"}})
+	}
+
+	return request, err
+}
+
+//addQuery sets the query parameters on a request, keeping those of the base url
+func addQuery(request *http.Request, values url.Values) {
+	query := request.URL.Query()
+	for key, value := range values {
+		query[key] = value
+	}
+	request.URL.RawQuery = query.Encode()
 }
 
 //ListSubjectsRequest returns the GET /subjects
 func ListSubjectsRequest(baseURL string) (*http.Request, error) {
-	return get(baseURL, "subjects")
+	return get(baseURL, "subjects", Call{Operation: OpListSubjects})
 }
 
 //ListVersionsRequest returns GET /subjects/<subject>/versions
 func ListVersionsRequest(baseURL string, subject Subject) (*http.Request, error) {
-	return get(baseURL, path.Join("subjects", string(subject), "versions"))
+	if err := subject.Validate(); err != nil {
+		return nil, err
+	}
+
+	return get(baseURL, endpoint("subjects", string(subject), "versions"), Call{Operation: OpListVersions, Subject: subject})
 }
 
 //GetConfigRequest returns the http.Request for the GET /config route
 func GetConfigRequest(baseURL string) (*http.Request, error) {
-	return get(baseURL, "config")
+	return get(baseURL, "config", Call{Operation: OpGetConfig})
 }
 
 //GetSubjectConfigRequest returns the http.Request for the GET /config route
 func GetSubjectConfigRequest(baseURL string, subject Subject) (*http.Request, error) {
-	return get(baseURL, path.Join("config", string(subject)))
+	if err := subject.Validate(); err != nil {
+		return nil, err
+	}
+
+	return get(baseURL, endpoint("config", string(subject)), Call{Operation: OpGetSubjectConfig, Subject: subject})
 }
 
 //PutSubjectConfigRequest returns the http.Request for the Put /config/<subject> route
 func PutSubjectConfigRequest(baseURL string, subject Subject, body *ConfigPutJSON) (*http.Request, error) {
-	return put(baseURL, path.Join("config", string(subject)), body)
+	if err := subject.Validate(); err != nil {
+		return nil, err
+	}
+
+	return put(baseURL, endpoint("config", string(subject)), body, Call{Operation: OpPutSubjectConfig, Subject: subject})
 }
 
 const schemaRegistryAccepts = "application/vnd.schemaregistry.v1+json,application/vnd.schemaregistry+json, application/json"
 
-func get(baseURL, query string) (request *http.Request, err error) {
+func get(baseURL, query string, call Call) (request *http.Request, err error) {
 	var u string
 	u, err = buildURL(baseURL, query)
 	if err != nil {
@@ -338,21 +521,22 @@ func get(baseURL, query string) (request *http.Request, err error) {
 
 	request, err = http.NewRequest("GET", u, nil)
 	if request != nil {
+		request = withCall(request, call)
 		request.Header.Add("Accept", schemaRegistryAccepts)
 	}
 
 	return
 }
 
-func put(baseURL, query string, body interface{}) (request *http.Request, err error) {
-	return putOrPost(baseURL, "PUT", query, body)
+func put(baseURL, query string, body interface{}, call Call) (request *http.Request, err error) {
+	return putOrPost(baseURL, "PUT", query, body, call)
 }
 
-func post(baseURL, query string, body interface{}) (request *http.Request, err error) {
-	return putOrPost(baseURL, "POST", query, body)
+func post(baseURL, query string, body interface{}, call Call) (request *http.Request, err error) {
+	return putOrPost(baseURL, "POST", query, body, call)
 }
 
-func putOrPost(baseURL, method string, query string, body interface{}) (request *http.Request, err error) {
+func putOrPost(baseURL, method string, query string, body interface{}, call Call) (request *http.Request, err error) {
 	var reader io.Reader
 	if body != nil {
 		var data []byte
@@ -371,6 +555,7 @@ func putOrPost(baseURL, method string, query string, body interface{}) (request
 
 	request, err = http.NewRequest(method, u, reader)
 	if request != nil {
+		request = withCall(request, call)
 		request.Header.Add("Accept", schemaRegistryAccepts)
 		request.Header.Add("Content-Type", "application/vnd.schemaregistry.v1+json")
 	}
@@ -378,12 +563,29 @@ func putOrPost(baseURL, method string, query string, body interface{}) (request
 	return
 }
 
+//endpoint joins the path escaped segments of a route, so a subject with a / or .. stays a single segment
+func endpoint(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(escaped, "/")
+}
+
+//buildURL appends the already escaped endpoint to the path of baseURL
 func buildURL(baseURL, endpoint string) (string, error) {
 	u, err := url.Parse(baseURL)
 	if err != nil {
 		return "", err
 	}
-	u.Path = path.Join(u.Path, endpoint)
+
+	rawPath := strings.TrimSuffix(u.EscapedPath(), "/") + "/" + endpoint
+	if u.Path, err = url.PathUnescape(rawPath); err != nil {
+		return "", err
+	}
+	u.RawPath = rawPath
+
 	return u.String(), nil
 }
 
@@ -392,9 +594,31 @@ type HTTPClient interface {
 	Do(request *http.Request) (*http.Response, error)
 }
 
+//UnavailableError is returned when the schema registry could not be reached or answered with a server error
+type UnavailableError struct {
+	Status int
+	Err    error
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+//IsUnavailable returns whether err means the schema registry could not be reached or answered with a server error
+func IsUnavailable(err error) bool {
+	var unavailable *UnavailableError
+	return errors.As(err, &unavailable)
+}
+
 func doJSON(restful HTTPClient, request *http.Request, response interface{}) (status int, body []byte, err error) {
 	res, err := restful.Do(request)
-	if err == nil {
+	if err != nil {
+		err = &UnavailableError{Err: err}
+	} else {
 		body, err = ioutil.ReadAll(res.Body)
 		res.Body.Close()
 		status = res.StatusCode
@@ -5,20 +5,35 @@ package main
 //license that can be found in the LICENSE file.
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/MediaMath/sr"
+	"github.com/MediaMath/sr/avro"
 	"github.com/urfave/cli/v2"
 )
 
 func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+//newApp is the sr command line app
+func newApp() *cli.App {
 	app := cli.NewApp()
 	app.Name = "sr"
 	app.Flags = []cli.Flag{
@@ -35,33 +50,146 @@ func main() {
 			Name:  "pretty",
 			Usage: "pretty print output",
 		},
+		&cli.StringSliceFlag{
+			Name:  "header",
+			Usage: "header to send with every request as 'Name: value', may be repeated",
+		},
+		&cli.StringFlag{
+			Name:    "context",
+			EnvVars: []string{"SCHEMA_REGISTRY_CONTEXT"},
+			Usage:   "registry context every command operates inside, like .tenant",
+		},
+		&cli.StringFlag{
+			Name:    "cache-dir",
+			EnvVars: []string{"SR_CACHE_DIR"},
+			Usage:   "directory of schemas by id to fall back on when the registry is unavailable",
+		},
 	}
 
 	app.Commands = []*cli.Command{
 		{
 			Name:   "add",
-			Usage:  "sr add foo-value < schema.json",
+			Usage:  "sr add (foo-value | --topic foo [--key] [--strategy record]) < schema.json",
 			Action: add,
+			Flags:  strategyFlags,
 		},
 		{
 			Name:   "exists",
-			Usage:  "sr exists foo-value < schema.json",
+			Usage:  "sr exists (foo-value | --topic foo [--key] [--strategy record]) < schema.json",
 			Action: exists,
+			Flags:  strategyFlags,
+		},
+		{
+			Name:   "subject",
+			Usage:  "sr subject foo-value parses a subject, sr subject --topic foo [--key] [--strategy record] [name of file | stdin] names one",
+			Action: subjectFunc,
+			Flags:  strategyFlags,
 		},
 		{
 			Name:   "compatible",
 			Usage:  "sr compatible foo-value 3 < schema.json",
 			Action: compatible,
 		},
+		{
+			Name:   "check-compatibility",
+			Usage:  "sr check-compatibility BACKWARD new.avsc [previous.avsc...] checks compatibility without a registry, previous schemas oldest first",
+			Action: checkCompatibility,
+		},
+		{
+			Name:   "diff-schema",
+			Usage:  "sr diff-schema foo-value 3 [4 | latest | name of file | stdin]",
+			Action: diffSchema,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "print the changes as json",
+				},
+			},
+		},
+		{
+			Name:   "lint",
+			Usage:  "sr lint [--config rules.yaml] [--previous old.avsc] [--format text|json|github] [schema files... | stdin]",
+			Action: lintFunc,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "config",
+					Usage: "yaml rule set, rules it does not configure use their defaults",
+				},
+				&cli.StringFlag{
+					Name:  "previous",
+					Usage: "schema the linted schemas replace, fields not in it must have defaults",
+				},
+				&cli.StringFlag{
+					Name:  "format",
+					Value: "text",
+					Usage: "text, json or github for GitHub Actions annotations",
+				},
+			},
+		},
+		{
+			Name:   "decode",
+			Usage:  "sr decode [--hex] [name of file | stdin] prints a framed avro message as avro json",
+			Action: decode,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "hex",
+					Usage: "the message is hex encoded",
+				},
+			},
+		},
+		{
+			Name:   "encode",
+			Usage:  "sr encode (--id 7 | --subject foo-value [--version 3]) [--hex] [name of file | stdin] frames avro json as binary",
+			Action: encode,
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "id",
+					Usage: "id of the schema",
+				},
+				&cli.StringFlag{
+					Name:  "subject",
+					Usage: "subject of the schema, when there is no id",
+				},
+				&cli.StringFlag{
+					Name:  "version",
+					Value: sr.Latest,
+					Usage: "version of the subject",
+				},
+				&cli.BoolFlag{
+					Name:  "hex",
+					Usage: "hex encode the message",
+				},
+			},
+		},
 		{
 			Name:   "ls",
-			Usage:  "sr ls [subject] [version]",
+			Usage:  "sr ls [--deleted | --deleted-only] [--prefix users-] [subject] [version]",
 			Action: ls,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "deleted",
+					Usage: "include soft deleted subjects or versions",
+				},
+				&cli.BoolFlag{
+					Name:  "deleted-only",
+					Usage: "list only soft deleted subjects or versions",
+				},
+				&cli.StringFlag{
+					Name:  "prefix",
+					Usage: "list only the subjects that start with the prefix",
+				},
+			},
 		},
 		{
 			Name:   "schema",
-			Usage:  "sr schema 7878",
+			Usage:  "sr schema 7878 [--where]",
 			Action: schema,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "where",
+					Usage: "print the subjects and versions the schema is registered as instead",
+				},
+			},
 		},
 		{
 			Name:   "config",
@@ -73,17 +201,164 @@ func main() {
 			Usage:  "sr set-config foo FULL",
 			Action: setConfig,
 		},
+		{
+			Name:   "contexts",
+			Usage:  "sr contexts",
+			Action: contexts,
+		},
 		{
 			Name:   "copy",
 			Usage:  "sr copy from-url to-url from-prefix to-prefix",
 			Action: copyFunc,
 		},
+		{
+			Name:   "fingerprint",
+			Usage:  "sr fingerprint [--canonical] < schema.json",
+			Action: fingerprint,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "canonical",
+					Usage: "print the parsing canonical form instead of the fingerprints",
+				},
+			},
+		},
+		{
+			Name:  "topic",
+			Usage: "work with the key and value subjects of topics",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "show",
+					Usage:  "sr topic show [--json] foo shows the key and value subjects of a topic side by side",
+					Action: topicShow,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "json",
+							Usage: "print the subjects as json",
+						},
+					},
+				},
+				{
+					Name:   "add",
+					Usage:  "sr topic add foo [--key key.avsc] [--value value.avsc] registers the key and value schemas of a topic",
+					Action: topicAdd,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "key",
+							Usage: "file of the key schema",
+						},
+						&cli.StringFlag{
+							Name:  "value",
+							Usage: "file of the value schema",
+						},
+					},
+				},
+				{
+					Name:   "ls",
+					Usage:  "sr topic ls [--json] lists topics with the subjects they have",
+					Action: topicLs,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "json",
+							Usage: "print the topics as json",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "refs",
+			Usage:  "sr refs SUBJECT [VERSION] [--reverse] [--dot]",
+			Action: refs,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "reverse",
+					Usage: "follow the versions that reference the subject rather than the ones it references",
+				},
+				&cli.BoolFlag{
+					Name:  "dot",
+					Usage: "print the graph in the Graphviz DOT language",
+				},
+			},
+		},
+		{
+			Name:   "search",
+			Usage:  "sr search [--all] [--dir export] [--record R] [--field F] [--type T] [--namespace N] [--doc D] [--json] [REGEX]",
+			Action: search,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "all",
+					Usage: "search every version rather than the latest of each subject",
+				},
+				&cli.StringFlag{
+					Name:  "dir",
+					Usage: "directory of <path escaped subject>/<version>.avsc files to search instead of the registry",
+				},
+				&cli.StringFlag{
+					Name:  "record",
+					Usage: "name of the named type or of the record of a field",
+				},
+				&cli.StringFlag{
+					Name:  "field",
+					Usage: "name of the field",
+				},
+				&cli.StringFlag{
+					Name:  "type",
+					Usage: "type of the field or named type, like long or com.mm.Address",
+				},
+				&cli.StringFlag{
+					Name:  "namespace",
+					Usage: "namespace of the named type or of the record of a field",
+				},
+				&cli.StringFlag{
+					Name:  "doc",
+					Usage: "case insensitive text in the doc",
+				},
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "print the hits as json",
+				},
+			},
+		},
+		{
+			Name:  "gen",
+			Usage: "generate code from schemas",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "go",
+					Usage:  "sr gen go [--package events] [--name Event] (subject [version] | --file schema.avsc) writes Go types for a schema",
+					Action: genGo,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "package",
+							Value: "schemas",
+							Usage: "package of the generated code",
+						},
+						&cli.StringFlag{
+							Name:  "name",
+							Usage: "prefix of the generated constants, the Go name of the schema by default",
+						},
+						&cli.StringFlag{
+							Name:  "file",
+							Usage: "generate from a schema file instead of the registry",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:  "cache",
+			Usage: "manage the on disk schema cache",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "warm",
+					Usage:  "sr --cache-dir DIR cache warm [subject...]",
+					Action: cacheWarm,
+				},
+			},
+		},
 	}
 
-	var err = app.Run(os.Args)
-	if err != nil {
-		log.Fatal(err)
-	}
+	return app
 }
 
 func setConfig(ctx *cli.Context) error {
@@ -111,8 +386,8 @@ func config(ctx *cli.Context) error {
 }
 
 func schema(ctx *cli.Context) error {
-	if ctx.Args().Len() != 1 {
-		log.Fatal("sr schema ID")
+	if ctx.Args().Len() < 1 {
+		log.Fatal("usage sr schema ID [--where]")
 	}
 
 	id, err := strconv.Atoi(ctx.Args().First())
@@ -120,18 +395,486 @@ func schema(ctx *cli.Context) error {
 		return err
 	}
 
+	//cli stops at the id, so the flags after it are parsed here
+	flags := flag.NewFlagSet("sr schema", flag.ContinueOnError)
+	where := flags.Bool("where", ctx.Bool("where"), "print the subjects and versions the schema is registered as instead")
+	if err := flags.Parse(ctx.Args().Tail()); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		log.Fatal("usage sr schema ID [--where]")
+	}
+
+	if *where {
+		versions, err := sr.FindVersionsByID(client(ctx), getAddress(ctx), uint32(id))
+		if err != nil {
+			return err
+		}
+
+		for _, version := range versions {
+			fmt.Printf("%v %v\n", version.Subject, version.Version)
+		}
+		return nil
+	}
+
+	if ctx.String("cache-dir") == "" {
+		out(sr.GetSchema(client(ctx), getAddress(ctx), uint32(id)))
+		return nil
+	}
+
+	out(getCache(ctx).GetSchema(uint32(id)))
+	return nil
+}
+
+func decode(ctx *cli.Context) error {
+	message, err := readMessage(ctx)
+	if err != nil {
+		return err
+	}
+
+	deserializer, err := sr.NewDeserializer(getCache(ctx), sr.EmptySchema)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := deserializer.ToJSON(message)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("pretty") {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, encoded, "", "\t"); err != nil {
+			return err
+		}
+		encoded = indented.Bytes()
+	}
+
+	fmt.Printf("%s\n", encoded)
+	return nil
+}
+
+func readMessage(ctx *cli.Context) ([]byte, error) {
+	inputFile, err := getStdinOrFile(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := ioutil.ReadAll(inputFile)
+	if err != nil || !ctx.Bool("hex") {
+		return message, err
+	}
+
+	return hex.DecodeString(strings.TrimSpace(string(message)))
+}
+
+func encode(ctx *cli.Context) error {
+	cache := getCache(ctx)
+
+	var id uint32
+	var schema sr.Schema
+	var err error
+	switch {
+	case ctx.IsSet("id"):
+		id = uint32(ctx.Int("id"))
+		schema, err = cache.GetSchema(id)
+	case ctx.IsSet("subject"):
+		id, schema, err = cache.GetVersion(sr.Subject(ctx.String("subject")), ctx.String("version"))
+	default:
+		log.Fatal("usage sr encode (--id 7 | --subject foo-value [--version 3]) [name of file | stdin]")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	parsed, err := avro.Parse(string(schema))
+	if err != nil {
+		return err
+	}
+
+	inputFile, err := getStdinOrFile(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := ioutil.ReadAll(inputFile)
+	if err != nil {
+		return err
+	}
+
+	payload, err := avro.JSONToBinary(parsed, encoded)
+	if err != nil {
+		return err
+	}
+
+	message := sr.Frame(id, payload)
+	if ctx.Bool("hex") {
+		fmt.Println(hex.EncodeToString(message))
+		return nil
+	}
+
+	_, err = os.Stdout.Write(message)
+	return err
+}
+
+func getCache(ctx *cli.Context) *sr.Cache {
+	cache := sr.NewCache(client(ctx), getAddress(ctx), 0)
+	if ctx.String("cache-dir") != "" {
+		cache.Disk = getDiskCache(ctx)
+	}
+
+	return cache
+}
+
+func fingerprint(ctx *cli.Context) error {
+	inputFile, err := getStdinOrFile(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	schemaString, err := ioutil.ReadAll(inputFile)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("canonical") {
+		out(sr.CanonicalForm(sr.Schema(schemaString)))
+		return nil
+	}
+
+	out(sr.Fingerprints(sr.Schema(schemaString)))
+	return nil
+}
+
+func cacheWarm(ctx *cli.Context) error {
 	address := getAddress(ctx)
+	disk := getDiskCache(ctx)
+
+	var subjects []sr.Subject
+	for _, arg := range ctx.Args().Slice() {
+		subjects = append(subjects, sr.Subject(arg))
+	}
+
+	total, err := disk.Warm(client(ctx), address, subjects...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d cached in %s\n", total, disk.Dir())
+	return nil
+}
+
+func topicShow(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		log.Fatal("usage sr topic show [--json] topic")
+	}
+
+	info, err := sr.DescribeTopic(client(ctx), getAddress(ctx), ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("json") {
+		output(ctx, info, nil)
+		return nil
+	}
+
+	column := func(subject *sr.SubjectInfo, field func(*sr.SubjectInfo) string) string {
+		if subject == nil {
+			return "-"
+		}
+		return field(subject)
+	}
+
+	rows := []struct {
+		name  string
+		field func(*sr.SubjectInfo) string
+	}{
+		{"subject", func(s *sr.SubjectInfo) string { return string(s.Subject) }},
+		{"versions", func(s *sr.SubjectInfo) string { return strings.Trim(fmt.Sprint(s.Versions), "[]") }},
+		{"id", func(s *sr.SubjectInfo) string { return fmt.Sprint(s.ID) }},
+		{"compatibility", func(s *sr.SubjectInfo) string { return string(s.Compatibility) }},
+		{"schema", func(s *sr.SubjectInfo) string { return compactSchema(s.Schema) }},
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "\tkey\tvalue\n")
+	for _, row := range rows {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", row.name, column(info.Key, row.field), column(info.Value, row.field))
+	}
+	return w.Flush()
+}
+
+//compactSchema is a schema on one line, as the registry returns it if it cannot be parsed
+func compactSchema(schema sr.Schema) string {
+	parsed, err := avro.Parse(string(schema))
+	if err != nil {
+		return string(schema)
+	}
+
+	return avro.Format(parsed)
+}
+
+func refs(ctx *cli.Context) error {
+	if ctx.Args().Len() < 1 {
+		log.Fatal("usage sr refs SUBJECT [VERSION] [--reverse] [--dot]")
+	}
 
-	out(sr.GetSchema(client(ctx), address, uint32(id)))
+	version, rest := sr.Latest, ctx.Args().Tail()
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		version, rest = rest[0], rest[1:]
+	}
+
+	//cli stops at the subject, so the flags after it are parsed here
+	flags := flag.NewFlagSet("sr refs", flag.ContinueOnError)
+	reverse := flags.Bool("reverse", ctx.Bool("reverse"), "follow the versions that reference the subject")
+	dot := flags.Bool("dot", ctx.Bool("dot"), "print the graph in the Graphviz DOT language")
+	if err := flags.Parse(rest); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		log.Fatal("usage sr refs SUBJECT [VERSION] [--reverse] [--dot]")
+	}
+
+	graph, err := sr.Dependencies(client(ctx), getAddress(ctx), sr.Subject(ctx.Args().First()), version, *reverse)
+	if err != nil {
+		return err
+	}
+
+	if *dot {
+		fmt.Print(graph.Dot())
+		return nil
+	}
+
+	for _, edge := range graph.Edges {
+		fmt.Println(strings.TrimSpace(fmt.Sprintf("%v/%v -> %v/%v %v", edge.From.Subject, edge.From.Version, edge.To.Subject, edge.To.Version, edge.Name)))
+	}
 	return nil
 }
 
+func search(ctx *cli.Context) error {
+	if ctx.Args().Len() > 1 {
+		log.Fatal("usage sr search [--all] [--dir export] [--record R] [--field F] [--type T] [--namespace N] [--doc D] [--json] [REGEX]")
+	}
+
+	query := sr.SearchQuery{
+		Record:      ctx.String("record"),
+		Field:       ctx.String("field"),
+		Type:        ctx.String("type"),
+		Namespace:   ctx.String("namespace"),
+		Doc:         ctx.String("doc"),
+		AllVersions: ctx.Bool("all"),
+	}
+
+	if ctx.Args().Len() == 1 {
+		pattern, err := regexp.Compile(ctx.Args().First())
+		if err != nil {
+			return err
+		}
+		query.Pattern = pattern
+	}
+
+	var source sr.SchemaSource = sr.RegistrySource{Client: client(ctx), URL: getAddress(ctx)}
+	if ctx.String("dir") != "" {
+		source = sr.DirectorySource(ctx.String("dir"))
+	}
+
+	hits, skipped, err := sr.Search(source, query)
+	if err != nil {
+		return err
+	}
+
+	for _, skip := range skipped {
+		if skip.Version == 0 {
+			fmt.Fprintf(os.Stderr, "skipped %v: %v\n", skip.Subject, skip.Err)
+		} else {
+			fmt.Fprintf(os.Stderr, "skipped %v/%v: %v\n", skip.Subject, skip.Version, skip.Err)
+		}
+	}
+
+	if ctx.Bool("json") {
+		if hits == nil {
+			hits = []sr.SearchHit{}
+		}
+		output(ctx, hits, nil)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "subject\tversion\tpath\ttype\n")
+	for _, hit := range hits {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", hit.Subject, hit.Version, hit.Path, hit.Type)
+	}
+	return w.Flush()
+}
+
+func topicAdd(ctx *cli.Context) error {
+	if ctx.Args().Len() < 1 {
+		log.Fatal("usage sr topic add topic [--key key.avsc] [--value value.avsc]")
+	}
+
+	//cli stops at the topic, so the flags after it are parsed here
+	flags := flag.NewFlagSet("sr topic add", flag.ContinueOnError)
+	files := map[string]*string{
+		"key":   flags.String("key", ctx.String("key"), "file of the key schema"),
+		"value": flags.String("value", ctx.String("value"), "file of the value schema"),
+	}
+	if err := flags.Parse(ctx.Args().Tail()); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 || (*files["key"] == "" && *files["value"] == "") {
+		log.Fatal("usage sr topic add topic [--key key.avsc] [--value value.avsc]")
+	}
+
+	schemas := make(map[string]sr.Schema)
+	for name, file := range files {
+		if *file == "" {
+			continue
+		}
+
+		schema, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		schemas[name] = sr.Schema(schema)
+	}
+
+	keyID, valueID, err := sr.RegisterTopic(client(ctx), getAddress(ctx), ctx.Args().First(), schemas["key"], schemas["value"])
+	if err != nil {
+		return err
+	}
+
+	if keyID != 0 {
+		fmt.Printf("key %v\n", keyID)
+	}
+	if valueID != 0 {
+		fmt.Printf("value %v\n", valueID)
+	}
+	return nil
+}
+
+func topicLs(ctx *cli.Context) error {
+	subjects, err := sr.ListSubjects(client(ctx), getAddress(ctx))
+	if err != nil {
+		return err
+	}
+
+	topics, others := sr.GroupByTopic(subjects)
+	if ctx.Bool("json") {
+		if topics == nil {
+			topics = []sr.TopicSubjects{}
+		}
+		output(ctx, topics, nil)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "topic\tkey\tvalue\n")
+	for _, topic := range topics {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", topic.Topic, orDash(topic.Key), orDash(topic.Value))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(others) > 0 {
+		fmt.Println("\nsubjects not named after a topic:")
+		for _, subject := range others {
+			fmt.Println(subject)
+		}
+	}
+	return nil
+}
+
+func orDash(subject sr.Subject) string {
+	if subject == sr.EmptySubject {
+		return "-"
+	}
+
+	return string(subject)
+}
+
+func genGo(ctx *cli.Context) error {
+	options := avro.GoOptions{Package: ctx.String("package"), Name: ctx.String("name")}
+
+	switch {
+	case ctx.IsSet("file"):
+		schema, err := ioutil.ReadFile(ctx.String("file"))
+		if err != nil {
+			return err
+		}
+		options.Source = strings.TrimSpace(string(schema))
+	case ctx.Args().Len() > 0:
+		subject := sr.Subject(ctx.Args().First())
+		version := sr.Latest
+		if ctx.Args().Len() > 1 {
+			version = ctx.Args().Get(1)
+		}
+
+		cache := getCache(ctx)
+		id, schema, err := cache.GetVersion(subject, version)
+		if err != nil {
+			return err
+		}
+
+		//the version number of latest is only known by looking the schema up
+		number, _, err := cache.HasSchema(subject, schema)
+		if err != nil {
+			return err
+		}
+
+		options.Subject, options.Version, options.ID, options.Source = string(subject), number, id, string(schema)
+	default:
+		log.Fatal("usage sr gen go [--package events] [--name Event] (subject [version] | --file schema.avsc)")
+	}
+
+	parsed, err := avro.Parse(options.Source)
+	if err != nil {
+		return err
+	}
+
+	code, err := avro.GenerateGo(parsed, options)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(code)
+	return err
+}
+
+func getDiskCache(ctx *cli.Context) *sr.DiskCache {
+	dir := ctx.String("cache-dir")
+	if dir == "" {
+		log.Fatal("cache-dir or SR_CACHE_DIR must be provided")
+	}
+
+	//schema ids are only unique within a context
+	if context := getContext(ctx); context != sr.DefaultContext {
+		dir = filepath.Join(dir, string(context))
+	}
+
+	disk, err := sr.NewDiskCache(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return disk
+}
+
 func ls(ctx *cli.Context) error {
 	address := getAddress(ctx)
 	argCount := ctx.Args().Len()
 	switch argCount {
 	case 0:
-		subjects, err := sr.ListSubjects(client(ctx), address)
+		subjects, err := sr.ListSubjectsWithOptions(client(ctx), address, sr.ListSubjectsOptions{
+			Deleted:       ctx.Bool("deleted"),
+			DeletedOnly:   ctx.Bool("deleted-only"),
+			SubjectPrefix: ctx.String("prefix"),
+		})
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -140,7 +883,10 @@ func ls(ctx *cli.Context) error {
 			fmt.Println(string(subject))
 		}
 	case 1:
-		out(sr.ListVersions(client(ctx), address, sr.Subject(ctx.Args().First())))
+		out(sr.ListVersionsWithOptions(client(ctx), address, sr.Subject(ctx.Args().First()), sr.ListVersionsOptions{
+			Deleted:     ctx.Bool("deleted"),
+			DeletedOnly: ctx.Bool("deleted-only"),
+		}))
 	case 2:
 		_, schema, err := sr.GetVersion(client(ctx), address, sr.Subject(ctx.Args().First()), ctx.Args().Get(1))
 		out(schema, err)
@@ -170,54 +916,311 @@ func compatible(ctx *cli.Context) error {
 		return err
 	}
 
-	out(sr.IsCompatible(client(ctx), address, sr.Subject(subject), version, sr.Schema(schemaString)))
+	result, err := sr.CheckIsCompatible(client(ctx), address, sr.Subject(subject), version, sr.Schema(schemaString))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(result.IsCompatible)
+	if result.Local {
+		fmt.Println("the registry gave no reasons, these were computed locally:")
+	}
+
+	for _, message := range result.Messages {
+		fmt.Println(message)
+	}
 	return nil
 }
 
-func exists(ctx *cli.Context) error {
+func checkCompatibility(ctx *cli.Context) error {
+	if ctx.Args().Len() < 2 {
+		log.Fatal("usage sr check-compatibility [level] [schema file] [previous schema files...]")
+	}
+
+	var schemas []sr.Schema
+	for _, file := range ctx.Args().Tail() {
+		schemaString, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		schemas = append(schemas, sr.Schema(schemaString))
+	}
+
+	incompatibilities, err := sr.CheckCompatibility(sr.Compatibility(ctx.Args().First()), schemas[0], schemas[1:])
+	if err != nil {
+		return err
+	}
+
+	for _, incompatibility := range incompatibilities {
+		fmt.Println(incompatibility)
+	}
+
+	if len(incompatibilities) > 0 {
+		return cli.Exit("", 1)
+	}
+
+	fmt.Println(true)
+	return nil
+}
+
+func diffSchema(ctx *cli.Context) error {
 	address := getAddress(ctx)
 
-	if ctx.Args().Len() < 1 {
-		log.Fatal("usage sr exists [subject] [name of file | stdin]")
+	if ctx.Args().Len() < 2 {
+		log.Fatal("usage sr diff-schema [subject] [version] [version | name of file | stdin]")
 	}
 
-	subject := ctx.Args().First()
+	subject := sr.Subject(ctx.Args().First())
+	version := ctx.Args().Get(1)
+
+	var changes []avro.Change
+	var err error
+	if other := ctx.Args().Get(2); isVersion(other) {
+		changes, err = sr.DiffVersions(client(ctx), address, subject, version, other)
+	} else {
+		changes, err = diffFile(ctx, address, subject, version)
+	}
 
-	inputFile, err := getStdinOrFile(ctx, 1)
 	if err != nil {
 		return err
 	}
 
+	if ctx.Bool("json") {
+		if changes == nil {
+			changes = []avro.Change{}
+		}
+		output(ctx, changes, nil)
+		return nil
+	}
+
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	return nil
+}
+
+func isVersion(s string) bool {
+	if s == sr.Latest {
+		return true
+	}
+
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func diffFile(ctx *cli.Context, address string, subject sr.Subject, version string) ([]avro.Change, error) {
+	inputFile, err := getStdinOrFile(ctx, 2)
+	if err != nil {
+		return nil, err
+	}
+
 	schemaString, err := ioutil.ReadAll(inputFile)
+	if err != nil {
+		return nil, err
+	}
+
+	_, registered, err := sr.GetVersion(client(ctx), address, subject, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return sr.DiffSchemas(registered, sr.Schema(schemaString))
+}
+
+func lintFunc(ctx *cli.Context) error {
+	config := sr.DefaultLintConfig()
+	if file := ctx.String("config"); file != "" {
+		configFile, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer configFile.Close()
+
+		if config, err = sr.ReadLintConfig(configFile); err != nil {
+			return fmt.Errorf("%v: %v", file, err)
+		}
+	}
+
+	linter, err := sr.NewLinter(config)
 	if err != nil {
 		return err
 	}
 
-	version, id, err := sr.HasSchema(client(ctx), address, sr.Subject(subject), sr.Schema(schemaString))
-	out(fmt.Sprintf("%v %v", version, id), err)
-	return err
+	previous := sr.EmptySchema
+	if file := ctx.String("previous"); file != "" {
+		previousString, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		previous = sr.Schema(previousString)
+	}
+
+	files := ctx.Args().Slice()
+	if len(files) == 0 {
+		files = []string{""}
+	}
+
+	findings := []sr.LintFinding{}
+	for _, file := range files {
+		var schemaString []byte
+		if file == "" {
+			schemaString, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			schemaString, err = ioutil.ReadFile(file)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fileFindings, err := linter.LintSchema(sr.Schema(schemaString), previous)
+		if err != nil {
+			return err
+		}
+
+		for _, finding := range fileFindings {
+			finding.File = file
+			findings = append(findings, finding)
+		}
+	}
+
+	switch ctx.String("format") {
+	case "json":
+		output(ctx, findings, nil)
+	case "github":
+		for _, finding := range findings {
+			fmt.Println(finding.GitHubAnnotation())
+		}
+	case "text":
+		for _, finding := range findings {
+			fmt.Println(finding)
+		}
+	default:
+		return fmt.Errorf("unknown format %q", ctx.String("format"))
+	}
+
+	if sr.HasErrors(findings) {
+		return cli.Exit("", 1)
+	}
+	return nil
 }
 
-func add(ctx *cli.Context) error {
-	address := getAddress(ctx)
+var strategyFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "topic",
+		Usage: "name the subject after a topic and the schema instead of giving it",
+	},
+	&cli.BoolFlag{
+		Name:  "key",
+		Usage: "the schema is for the keys of the topic rather than its values",
+	},
+	&cli.StringFlag{
+		Name:  "strategy",
+		Value: "topic",
+		Usage: "how subjects are named after topics: topic, record or topic-record",
+	},
+}
 
-	if ctx.Args().Len() < 1 {
-		log.Fatal("usage sr add [subject] [name of file | stdin]")
+//subjectAndSchema reads the schema from the argument after the subject, or the first argument if the subject is named
+//after --topic
+func subjectAndSchema(ctx *cli.Context, usage string) (sr.Subject, sr.Schema, error) {
+	schemaIndex := 1
+	if ctx.IsSet("topic") {
+		schemaIndex = 0
+	} else if ctx.Args().Len() < 1 {
+		log.Fatal(usage)
 	}
 
-	subject := ctx.Args().First()
+	inputFile, err := getStdinOrFile(ctx, schemaIndex)
+	if err != nil {
+		return sr.EmptySubject, sr.EmptySchema, err
+	}
+
+	schemaString, err := ioutil.ReadAll(inputFile)
+	if err != nil {
+		return sr.EmptySubject, sr.EmptySchema, err
+	}
+
+	if !ctx.IsSet("topic") {
+		return sr.Subject(ctx.Args().First()), sr.Schema(schemaString), nil
+	}
+
+	subject, err := strategySubject(ctx, sr.Schema(schemaString))
+	return subject, sr.Schema(schemaString), err
+}
+
+func strategySubject(ctx *cli.Context, schema sr.Schema) (sr.Subject, error) {
+	strategy, err := sr.SubjectNameStrategyNamed(ctx.String("strategy"))
+	if err != nil {
+		return sr.EmptySubject, err
+	}
+
+	parsed, err := avro.Parse(string(schema))
+	if err != nil {
+		return sr.EmptySubject, err
+	}
+
+	return strategy.Subject(ctx.String("topic"), ctx.Bool("key"), parsed)
+}
+
+func subjectFunc(ctx *cli.Context) error {
+	if ctx.IsSet("topic") {
+		subject, _, err := subjectAndSchema(ctx, "")
+		out(subject, err)
+		return nil
+	}
+
+	if ctx.Args().Len() != 1 {
+		log.Fatal("usage sr subject (subject | --topic foo [--key] [--strategy record] [name of file | stdin])")
+	}
+
+	name, strategy := sr.ParseSubject(sr.Subject(ctx.Args().First()))
+	if strategy == nil {
+		return fmt.Errorf("%q is not named by any subject name strategy", ctx.Args().First())
+	}
+
+	output(ctx, struct {
+		sr.SubjectName
+		Strategy string `json:"strategy"`
+	}{name, strategyName(strategy)}, nil)
+	return nil
+}
+
+func strategyName(strategy sr.SubjectNameStrategy) string {
+	switch strategy.(type) {
+	case sr.TopicNameStrategy:
+		return "topic"
+	case sr.RecordNameStrategy:
+		return "record"
+	case sr.TopicRecordNameStrategy:
+		return "topic-record"
+	}
+
+	return fmt.Sprintf("%T", strategy)
+}
+
+func exists(ctx *cli.Context) error {
+	address := getAddress(ctx)
 
-	inputFile, err := getStdinOrFile(ctx, 1)
+	subject, schema, err := subjectAndSchema(ctx, "usage sr exists (subject | --topic foo [--key] [--strategy record]) [name of file | stdin]")
 	if err != nil {
 		return err
 	}
 
-	schemaString, err := ioutil.ReadAll(inputFile)
+	version, id, err := sr.HasSchema(client(ctx), address, subject, schema)
+	out(fmt.Sprintf("%v %v", version, id), err)
+	return err
+}
+
+func add(ctx *cli.Context) error {
+	address := getAddress(ctx)
+
+	subject, schema, err := subjectAndSchema(ctx, "usage sr add (subject | --topic foo [--key] [--strategy record]) [name of file | stdin]")
 	if err != nil {
 		return err
 	}
 
-	id, err := sr.Register(http.DefaultClient, address, sr.Subject(subject), sr.Schema(string(schemaString)))
+	id, err := sr.Register(client(ctx), address, subject, schema)
 	if err != nil {
 		return err
 	}
@@ -261,18 +1264,52 @@ func out(r interface{}, err error) {
 }
 
 func client(ctx *cli.Context) sr.HTTPClient {
-	return http.DefaultClient
+	headers := http.Header{}
+	for _, header := range ctx.StringSlice("header") {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 {
+			log.Fatalf("header %q must be 'Name: value'", header)
+		}
+		headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	middlewares := []sr.Middleware{sr.WithUserAgent("sr"), sr.WithHeaders(headers)}
+	if ctx.Bool("verbose") {
+		middlewares = append(middlewares, sr.WithLogging(log.New(os.Stderr, "", log.LstdFlags)))
+	}
+
+	return sr.Chain(http.DefaultClient, middlewares...)
 }
 
-func getAddress(ctx *cli.Context) string {
-	address := ctx.String("host")
-	if address == "" {
+func getHost(ctx *cli.Context) string {
+	host := ctx.String("host")
+	if host == "" {
 		log.Fatal("host or SCHEMA_REGISTRY_URL must be provided")
 	}
 
+	return host
+}
+
+//getAddress is the url of the registry inside the --context
+func getAddress(ctx *cli.Context) string {
+	address, err := sr.ContextURL(getHost(ctx), getContext(ctx))
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	return address
 }
 
+func getContext(ctx *cli.Context) sr.Context {
+	return sr.ContextNamed(ctx.String("context"))
+}
+
+func contexts(ctx *cli.Context) error {
+	//contexts are listed by the registry itself, not inside one
+	out(sr.ListContexts(client(ctx), getHost(ctx)))
+	return nil
+}
+
 func copyFunc(ctx *cli.Context) error {
 	if ctx.Args().Len() < 4 {
 		log.Fatal("usage sr copy [sr from url] [sr to url] [from prefix] [to prefix]")
@@ -283,7 +1320,7 @@ func copyFunc(ctx *cli.Context) error {
 	var fromPrefix = ctx.Args().Get(2)
 	var toPrefix = ctx.Args().Get(3)
 
-	var total, err = sr.Copy(http.DefaultClient, fromURL, toURL, fromPrefix, toPrefix)
+	var total, err = sr.Copy(client(ctx), fromURL, toURL, fromPrefix, toPrefix)
 	if err != nil {
 		return err
 	}
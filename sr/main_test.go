@@ -0,0 +1,53 @@
+package main
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//run runs the sr app with args and returns what it printed
+func run(t *testing.T, args ...string) string {
+	read, write, err := os.Pipe()
+	require.NoError(t, err)
+
+	stdout := os.Stdout
+	os.Stdout = write
+	defer func() { os.Stdout = stdout }()
+
+	err = newApp().Run(append([]string{"sr"}, args...))
+	write.Close()
+	require.NoError(t, err)
+
+	printed, err := ioutil.ReadAll(read)
+	require.NoError(t, err)
+	return string(printed)
+}
+
+func TestSchemaWhere(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schemas/ids/7878/versions":
+			fmt.Fprint(w, `[{"subject":"users-value","version":3},{"subject":"events-value","version":1}]`)
+		case "/schemas/ids/7878":
+			fmt.Fprint(w, `{"schema":"\"long\""}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	assert.Equal(t, "users-value 3\nevents-value 1\n", run(t, "--host", ts.URL, "schema", "7878", "--where"))
+	assert.Equal(t, "users-value 3\nevents-value 1\n", run(t, "--host", ts.URL, "schema", "--where", "7878"))
+	assert.Equal(t, "\"long\"\n", run(t, "--host", ts.URL, "schema", "7878"))
+}
@@ -131,6 +131,51 @@ func TestListVersions(t *testing.T) {
 	assert.Equal(t, 4, result[1])
 }
 
+func TestListOptions(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Path+"?"+r.URL.RawQuery)
+		w.Write([]byte("[]"))
+	}))
+	defer ts.Close()
+
+	_, err := ListSubjectsWithOptions(tstClient(), ts.URL, ListSubjectsOptions{Deleted: true, SubjectPrefix: "users-"})
+	require.NoError(t, err)
+	_, err = ListSubjectsWithOptions(tstClient(), ts.URL, ListSubjectsOptions{DeletedOnly: true})
+	require.NoError(t, err)
+	_, err = ListSubjects(tstClient(), ts.URL)
+	require.NoError(t, err)
+	_, err = ListVersionsWithOptions(tstClient(), ts.URL, "goo", ListVersionsOptions{Deleted: true})
+	require.NoError(t, err)
+	_, err = ListVersionsWithOptions(tstClient(), ts.URL, "goo", ListVersionsOptions{DeletedOnly: true})
+	require.NoError(t, err)
+	_, err = ListSubjectsWithOptions(tstClient(), ts.URL+"?tenant=a", ListSubjectsOptions{Deleted: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"/subjects?deleted=true&subjectPrefix=users-",
+		"/subjects?deletedOnly=true",
+		"/subjects?",
+		"/subjects/goo/versions?deleted=true",
+		"/subjects/goo/versions?deletedOnly=true",
+		"/subjects?deleted=true&tenant=a",
+	}, queries)
+}
+
+func TestListSubjectsPrefix(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	for _, subject := range []Subject{"users-key", "users-value", "orders-value"} {
+		_, err := Register(tstClient(), ts.URL, subject, userV1)
+		require.NoError(t, err)
+	}
+
+	subjects, err := ListSubjectsWithOptions(tstClient(), ts.URL, ListSubjectsOptions{SubjectPrefix: "users-"})
+	require.NoError(t, err)
+	assert.Equal(t, []Subject{"users-key", "users-value"}, subjects)
+}
+
 func TestGetVersion(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "GET" {
@@ -241,3 +286,129 @@ func compatibilityServer(result Compatibility, method string, path string) *http
 	}))
 
 }
+
+func TestCheckIsCompatibleVerbose(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/compatibility/subjects/goo/versions/latest" {
+			http.Error(w, fmt.Sprintf("Wrong request: %v %v", r.Method, r.URL.Path), 500)
+		}
+
+		if r.URL.Query().Get("verbose") != "true" {
+			http.Error(w, "Expected verbose", 500)
+		}
+
+		_, err := w.Write([]byte(`{"is_compatible":false,"messages":["Incompatibility{type:NAME_MISMATCH}"]}`))
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	result, err := CheckIsCompatible(tstClient(), ts.URL, Subject("goo"), "latest", Schema(`"int"`))
+	require.NoError(t, err)
+	assert.False(t, result.IsCompatible)
+	assert.False(t, result.Local)
+	assert.Equal(t, []string{"Incompatibility{type:NAME_MISMATCH}"}, result.Messages)
+
+	request, err := CheckIsCompatibleVerboseRequest(ts.URL+"/?tenant=a", Subject("goo"), "latest", &SchemaJSON{})
+	require.NoError(t, err)
+	assert.Equal(t, "tenant=a&verbose=true", request.URL.RawQuery, "the query of the base url is kept")
+
+	request, err = CheckIsCompatibleRequest(ts.URL+"/?tenant=a", Subject("goo"), "latest", &SchemaJSON{})
+	require.NoError(t, err)
+	assert.Equal(t, "tenant=a", request.URL.RawQuery, "only the verbose request asks for reasons")
+}
+
+func TestIsCompatibleIsOneRequest(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.String())
+		w.Write([]byte(`{"is_compatible":false}`))
+	}))
+	defer ts.Close()
+
+	is, err := IsCompatible(tstClient(), ts.URL, Subject("goo"), "latest", Schema(`"int"`))
+	require.NoError(t, err)
+	assert.False(t, is)
+	assert.Equal(t, []string{"/compatibility/subjects/goo/versions/latest"}, requests, "no verbose messages or local explanation")
+}
+
+func TestCheckIsCompatibleExplainsLocally(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response string
+		switch r.URL.Path {
+		case "/compatibility/subjects/goo/versions/2":
+			response = `{"is_compatible":false}`
+		case "/config/goo":
+			response = `{"compatibilityLevel":"FORWARD_TRANSITIVE"}`
+		case "/subjects/goo/versions/2":
+			response = `{"id":3,"version":2,"schema":"{\"type\":\"record\",\"name\":\"R\",\"fields\":[{\"name\":\"a\",\"type\":\"int\"}]}"}`
+		default:
+			http.Error(w, fmt.Sprintf("Wrong path: %v", r.URL.Path), 500)
+			return
+		}
+
+		_, err := w.Write([]byte(response))
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	result, err := CheckIsCompatible(tstClient(), ts.URL, Subject("goo"), "2", Schema(`{"type":"record","name":"R","fields":[]}`))
+	require.NoError(t, err)
+	assert.False(t, result.IsCompatible)
+	assert.True(t, result.Local)
+	assert.Equal(t, []string{"version 2 cannot read data written with this schema: a: reader field a has no default and is missing from writer R"}, result.Messages)
+}
+
+func TestSubjectsAreEscaped(t *testing.T) {
+	paths := map[Subject]string{
+		"a/b":    "/registry/subjects/a%2Fb/versions",
+		"a b":    "/registry/subjects/a%20b/versions",
+		"100%":   "/registry/subjects/100%25/versions",
+		"..a":    "/registry/subjects/..a/versions",
+		"x?y#z":  "/registry/subjects/x%3Fy%23z/versions",
+		"plain1": "/registry/subjects/plain1/versions",
+	}
+
+	for subject, expected := range paths {
+		request, err := ListVersionsRequest("http://example.com/registry/", subject)
+		require.NoError(t, err)
+		assert.Equal(t, expected, request.URL.EscapedPath(), string(subject))
+		assert.Empty(t, request.URL.RawQuery, string(subject))
+	}
+
+	request, err := CheckIsCompatibleRequest("http://example.com", "a/../b", "latest", &SchemaJSON{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/compatibility/subjects/a%2F..%2Fb/versions/latest", request.URL.String())
+
+	_, err = ListVersionsRequest("http://example.com", "..")
+	assert.Error(t, err)
+
+	_, err = GetVersionRequest("http://example.com", EmptySubject, "latest")
+	assert.Error(t, err)
+}
+
+func TestTrickySubjectsRoundTrip(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	subjects := []Subject{"a/b", "a b", "100%", "a/../b", "x?y#z"}
+	for i, subject := range subjects {
+		schema := Schema(fmt.Sprintf(`{"type": "fixed", "name": "f", "size": %v}`, i+1))
+		id, err := Register(tstClient(), ts.URL, subject, schema)
+		require.NoError(t, err, string(subject))
+
+		latestID, latest, err := GetLatestSchema(tstClient(), ts.URL, subject)
+		require.NoError(t, err, string(subject))
+		assert.Equal(t, id, latestID, string(subject))
+		assert.Equal(t, schema, latest, string(subject))
+
+		_, err = SetSubjectCompatibility(tstClient(), ts.URL, subject, None)
+		require.NoError(t, err, string(subject))
+	}
+
+	listed, err := ListSubjects(tstClient(), ts.URL)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, subjects, listed)
+
+	_, err = Register(tstClient(), ts.URL, "", Schema(`"string"`))
+	assert.Error(t, err)
+}
@@ -4,7 +4,14 @@ package sr
 //Use of this source code is governed by a BSD-style
 //license that can be found in the LICENSE file.
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/MediaMath/sr/avro"
+)
 
 //Subject is *not* a topic.  Subject is a schema registry abstraction, topic is a kafka one.  For instance the kafka rest proxy assumes that for
 //topic "foo" there can be 2 subjects foo-key and foo-value.  foo-key will store the schema for the key field (if any) and foo-value will store the
@@ -14,6 +21,31 @@ type Subject string
 //EmptySubject is just a place holder for the empty string.
 const EmptySubject = Subject("")
 
+//Validate returns an error if the subject cannot be used in a schema registry route: it is empty, not utf-8, has control
+//characters or is a . or .. path segment.  A qualified subject must have a valid context and subject.  Other characters
+//like / and % are escaped when the subject is put into a url.
+func (s Subject) Validate() error {
+	switch {
+	case s == EmptySubject:
+		return fmt.Errorf("subject is empty")
+	case s == "." || s == "..":
+		return fmt.Errorf("subject %q is a relative path", string(s))
+	case !utf8.ValidString(string(s)):
+		return fmt.Errorf("subject %q is not utf-8", string(s))
+	case strings.IndexFunc(string(s), unicode.IsControl) >= 0:
+		return fmt.Errorf("subject %q has control characters", string(s))
+	}
+
+	if context, unqualified := s.Context(); context != DefaultContext {
+		if err := context.Validate(); err != nil {
+			return err
+		}
+		return unqualified.Validate()
+	}
+
+	return nil
+}
+
 //ValueSubject takes a topic name and turns it into what kafka-rest assumes is the name for value schemas
 func ValueSubject(topic string) Subject {
 	if topic == "" {
@@ -31,3 +63,144 @@ func KeySubject(topic string) Subject {
 
 	return Subject(fmt.Sprintf("%s-key", topic))
 }
+
+//SubjectName is what a subject says about the schemas registered on it
+type SubjectName struct {
+	//Context is set for subjects qualified with a context other than the DefaultContext
+	Context Context `json:"context,omitempty"`
+	//Topic is empty for subjects named by RecordNameStrategy
+	Topic string `json:"topic,omitempty"`
+	//Key and Value are whether the subject is for the keys or the values of the topic.  Only TopicNameStrategy says.
+	Key   bool `json:"key,omitempty"`
+	Value bool `json:"value,omitempty"`
+	//Record is the full name of the schema, which TopicNameStrategy does not say
+	Record string `json:"record,omitempty"`
+}
+
+//SubjectNameStrategy decides the subject a schema is registered on, like the subject.name.strategy of the Confluent
+//serializers
+type SubjectNameStrategy interface {
+	//Subject returns the subject of schema when it is for the keys or values of topic
+	Subject(topic string, isKey bool, schema avro.Schema) (Subject, error)
+	//Parse reverses Subject as far as the subject tells, it is false if the strategy cannot have named the subject
+	Parse(subject Subject) (SubjectName, bool)
+}
+
+//TopicNameStrategy names subjects topic-key and topic-value, whatever the schema.  It is the default of the Confluent
+//serializers and what ValueSubject and KeySubject do.
+type TopicNameStrategy struct{}
+
+//Subject returns KeySubject or ValueSubject of topic
+func (TopicNameStrategy) Subject(topic string, isKey bool, schema avro.Schema) (Subject, error) {
+	if topic == "" {
+		return EmptySubject, fmt.Errorf("no topic to name the subject after")
+	}
+
+	if isKey {
+		return KeySubject(topic), nil
+	}
+
+	return ValueSubject(topic), nil
+}
+
+//Parse splits the -key or -value suffix from the topic
+func (TopicNameStrategy) Parse(subject Subject) (SubjectName, bool) {
+	s := string(subject)
+	switch {
+	case strings.HasSuffix(s, "-key") && len(s) > len("-key"):
+		return SubjectName{Topic: strings.TrimSuffix(s, "-key"), Key: true}, true
+	case strings.HasSuffix(s, "-value") && len(s) > len("-value"):
+		return SubjectName{Topic: strings.TrimSuffix(s, "-value"), Value: true}, true
+	}
+
+	return SubjectName{}, false
+}
+
+//RecordNameStrategy names subjects after the full name of the schema, so a topic can have schemas of several types
+//and a type has the same subject on every topic
+type RecordNameStrategy struct{}
+
+//Subject returns the full name of schema, which must be a record, enum or fixed
+func (RecordNameStrategy) Subject(topic string, isKey bool, schema avro.Schema) (Subject, error) {
+	name, err := recordName(schema)
+	return Subject(name), err
+}
+
+//Parse returns the record name if the subject is a valid full name
+func (RecordNameStrategy) Parse(subject Subject) (SubjectName, bool) {
+	if !avro.ValidFullName(string(subject)) {
+		return SubjectName{}, false
+	}
+
+	return SubjectName{Record: string(subject)}, true
+}
+
+//TopicRecordNameStrategy names subjects topic-fullname, so a topic can have schemas of several types that evolve
+//independently on each topic
+type TopicRecordNameStrategy struct{}
+
+//Subject returns the topic and the full name of schema, which must be a record, enum or fixed
+func (TopicRecordNameStrategy) Subject(topic string, isKey bool, schema avro.Schema) (Subject, error) {
+	if topic == "" {
+		return EmptySubject, fmt.Errorf("no topic to name the subject after")
+	}
+
+	name, err := recordName(schema)
+	if err != nil {
+		return EmptySubject, err
+	}
+
+	return Subject(topic + "-" + name), nil
+}
+
+//Parse splits the subject at the last -, full names cannot have one
+func (TopicRecordNameStrategy) Parse(subject Subject) (SubjectName, bool) {
+	dash := strings.LastIndex(string(subject), "-")
+	if dash <= 0 || !avro.ValidFullName(string(subject[dash+1:])) {
+		return SubjectName{}, false
+	}
+
+	return SubjectName{Topic: string(subject[:dash]), Record: string(subject[dash+1:])}, true
+}
+
+func recordName(schema avro.Schema) (string, error) {
+	named, ok := schema.(avro.NamedSchema)
+	if !ok {
+		return "", fmt.Errorf("a %v schema has no name to name the subject after", schema.Type())
+	}
+
+	return named.FullName(), nil
+}
+
+//SubjectNameStrategyNamed returns a strategy by its short name, topic, record or topic-record, or the name of its
+//Confluent class, like io.confluent.kafka.serializers.subject.RecordNameStrategy
+func SubjectNameStrategyNamed(name string) (SubjectNameStrategy, error) {
+	switch strings.ToLower(name[strings.LastIndex(name, ".")+1:]) {
+	case "topic", "topicnamestrategy":
+		return TopicNameStrategy{}, nil
+	case "record", "recordnamestrategy":
+		return RecordNameStrategy{}, nil
+	case "topic-record", "topicrecordnamestrategy":
+		return TopicRecordNameStrategy{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown subject name strategy %q, not topic, record or topic-record", name)
+}
+
+//ParseSubject reverses the strategy that most likely named subject.  Subjects ending in -key or -value are taken to
+//be named by TopicNameStrategy, then ones ending in -fullname by TopicRecordNameStrategy and then full names by
+//RecordNameStrategy.  The context of a qualified subject is split off first.  It returns nil for subjects none of
+//them could have named.
+func ParseSubject(subject Subject) (SubjectName, SubjectNameStrategy) {
+	context, subject := subject.Context()
+	for _, strategy := range []SubjectNameStrategy{TopicNameStrategy{}, TopicRecordNameStrategy{}, RecordNameStrategy{}} {
+		if name, ok := strategy.Parse(subject); ok {
+			if context != DefaultContext {
+				name.Context = context
+			}
+			return name, strategy
+		}
+	}
+
+	return SubjectName{}, nil
+}
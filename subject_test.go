@@ -0,0 +1,88 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"testing"
+
+	"github.com/MediaMath/sr/avro"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubjectNameStrategies(t *testing.T) {
+	record := avro.MustParse(`{"type": "record", "name": "Event", "namespace": "com.mm", "fields": []}`)
+	long := avro.MustParse(`"long"`)
+
+	cases := []struct {
+		strategy SubjectNameStrategy
+		isKey    bool
+		schema   avro.Schema
+		subject  Subject
+		name     SubjectName
+	}{
+		{TopicNameStrategy{}, false, long, "my-events-value", SubjectName{Topic: "my-events", Value: true}},
+		{TopicNameStrategy{}, true, record, "my-events-key", SubjectName{Topic: "my-events", Key: true}},
+		{RecordNameStrategy{}, false, record, "com.mm.Event", SubjectName{Record: "com.mm.Event"}},
+		{TopicRecordNameStrategy{}, true, record, "my-events-com.mm.Event", SubjectName{Topic: "my-events", Record: "com.mm.Event"}},
+	}
+
+	for _, c := range cases {
+		subject, err := c.strategy.Subject("my-events", c.isKey, c.schema)
+		require.NoError(t, err)
+		assert.Equal(t, c.subject, subject)
+
+		name, ok := c.strategy.Parse(subject)
+		assert.True(t, ok, "%v", subject)
+		assert.Equal(t, c.name, name)
+
+		parsed, strategy := ParseSubject(subject)
+		assert.Equal(t, c.name, parsed)
+		assert.Equal(t, c.strategy, strategy)
+	}
+
+	_, err := RecordNameStrategy{}.Subject("events", false, long)
+	assert.Error(t, err)
+
+	_, err = TopicRecordNameStrategy{}.Subject("", false, record)
+	assert.Error(t, err)
+
+	_, err = TopicNameStrategy{}.Subject("", false, record)
+	assert.Error(t, err)
+
+	_, ok := TopicRecordNameStrategy{}.Parse("-com.mm.Event")
+	assert.False(t, ok)
+
+	_, strategy := ParseSubject("not a-subject!")
+	assert.Nil(t, strategy)
+}
+
+func TestSubjectNameStrategyNamed(t *testing.T) {
+	names := map[string]SubjectNameStrategy{
+		"topic":        TopicNameStrategy{},
+		"Record":       RecordNameStrategy{},
+		"topic-record": TopicRecordNameStrategy{},
+		"io.confluent.kafka.serializers.subject.TopicRecordNameStrategy": TopicRecordNameStrategy{},
+	}
+
+	for name, expected := range names {
+		strategy, err := SubjectNameStrategyNamed(name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, strategy)
+	}
+
+	_, err := SubjectNameStrategyNamed("nope")
+	assert.Error(t, err)
+}
+
+func TestSubjectValidate(t *testing.T) {
+	for _, subject := range []Subject{"foo-value", "a/b", "a b", "100%", "..a", "ünïcode"} {
+		assert.NoError(t, subject.Validate(), string(subject))
+	}
+
+	for _, subject := range []Subject{EmptySubject, ".", "..", "a\nb", "tab\t", Subject([]byte{0xff, 'a'})} {
+		assert.Error(t, subject.Validate(), "%q", string(subject))
+	}
+}
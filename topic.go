@@ -0,0 +1,171 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+//TopicSubjects are the key and value subjects TopicNameStrategy names after a topic, EmptySubject if there is none
+type TopicSubjects struct {
+	Topic string  `json:"topic"`
+	Key   Subject `json:"key,omitempty"`
+	Value Subject `json:"value,omitempty"`
+}
+
+//GroupByTopic groups subjects by the topic they are the keys or values of, sorted by topic.  Subjects that are not
+//named topic-key or topic-value are returned as others.
+func GroupByTopic(subjects []Subject) (topics []TopicSubjects, others []Subject) {
+	byTopic := make(map[string]*TopicSubjects)
+	for _, subject := range subjects {
+		name, ok := TopicNameStrategy{}.Parse(subject)
+		if !ok {
+			others = append(others, subject)
+			continue
+		}
+
+		topic, ok := byTopic[name.Topic]
+		if !ok {
+			topic = &TopicSubjects{Topic: name.Topic}
+			byTopic[name.Topic] = topic
+		}
+
+		if name.Key {
+			topic.Key = subject
+		} else {
+			topic.Value = subject
+		}
+	}
+
+	for _, topic := range byTopic {
+		topics = append(topics, *topic)
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Topic < topics[j].Topic })
+
+	return topics, others
+}
+
+//SubjectInfo is the versions, latest schema and compatibility of a subject
+type SubjectInfo struct {
+	Subject       Subject       `json:"subject"`
+	Versions      []int         `json:"versions"`
+	ID            uint32        `json:"id"`
+	Schema        Schema        `json:"schema"`
+	Compatibility Compatibility `json:"compatibility"`
+}
+
+//DescribeSubject returns the versions, latest schema and compatibility of a subject
+func DescribeSubject(client HTTPClient, url string, subject Subject) (SubjectInfo, error) {
+	versions, err := ListVersions(client, url, subject)
+	if err != nil {
+		return SubjectInfo{Subject: subject}, err
+	}
+
+	return describeVersions(client, url, subject, versions)
+}
+
+func describeVersions(client HTTPClient, url string, subject Subject, versions []int) (info SubjectInfo, err error) {
+	info.Subject, info.Versions = subject, versions
+
+	if info.ID, info.Schema, err = GetLatestSchema(client, url, subject); err != nil {
+		return
+	}
+
+	info.Compatibility, err = GetSubjectDerivedCompatibility(client, url, subject)
+	return
+}
+
+//TopicInfo describes the key and value subjects of a topic, which are nil if they are not registered
+type TopicInfo struct {
+	Topic string       `json:"topic"`
+	Key   *SubjectInfo `json:"key"`
+	Value *SubjectInfo `json:"value"`
+}
+
+//DescribeTopic returns the key and value subjects TopicNameStrategy names after a topic.  The subjects are asked for
+//directly, the registry is only listed if it answers a subject with a 404 that does not say the subject is not found.
+func DescribeTopic(client HTTPClient, url string, topic string) (TopicInfo, error) {
+	info := TopicInfo{Topic: topic}
+
+	var listed map[Subject]bool
+	for _, candidate := range []struct {
+		subject   Subject
+		described **SubjectInfo
+	}{{KeySubject(topic), &info.Key}, {ValueSubject(topic), &info.Value}} {
+		req, err := ListVersionsRequest(url, candidate.subject)
+		if err != nil {
+			return info, err
+		}
+
+		var versions []int
+		err = getByID(client, req, &versions)
+		if notFound, ok := err.(*byIDError); ok && notFound.status == http.StatusNotFound {
+			if notFound.code != subjectNotFound {
+				if listed == nil {
+					if listed, err = listedSubjects(client, url); err != nil {
+						return info, err
+					}
+				}
+
+				//a subject that is listed but cannot be read is an error rather than missing
+				if listed[candidate.subject] {
+					return info, notFound
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return info, err
+		}
+
+		subjectInfo, err := describeVersions(client, url, candidate.subject, versions)
+		if err != nil {
+			return info, err
+		}
+		*candidate.described = &subjectInfo
+	}
+
+	if info.Key == nil && info.Value == nil {
+		return info, fmt.Errorf("topic %q has no subject %v or %v", topic, KeySubject(topic), ValueSubject(topic))
+	}
+
+	return info, nil
+}
+
+func listedSubjects(client HTTPClient, url string) (map[Subject]bool, error) {
+	subjects, err := ListSubjects(client, url)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make(map[Subject]bool)
+	for _, subject := range subjects {
+		listed[subject] = true
+	}
+
+	return listed, nil
+}
+
+//RegisterTopic registers the key and value schemas of a topic on the subjects TopicNameStrategy names after it.  An
+//EmptySchema is not registered and has id 0.
+func RegisterTopic(client HTTPClient, url string, topic string, key, value Schema) (keyID, valueID uint32, err error) {
+	if topic == "" {
+		return 0, 0, fmt.Errorf("no topic to register schemas for")
+	}
+
+	if key != EmptySchema {
+		if keyID, err = Register(client, url, KeySubject(topic), key); err != nil {
+			return
+		}
+	}
+
+	if value != EmptySchema {
+		valueID, err = Register(client, url, ValueSubject(topic), value)
+	}
+
+	return
+}
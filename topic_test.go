@@ -0,0 +1,100 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupByTopic(t *testing.T) {
+	topics, others := GroupByTopic([]Subject{"b-value", "a-key", "com.mm.Event", "a-value", "c-key", "-value"})
+	assert.Equal(t, []TopicSubjects{
+		{Topic: "a", Key: "a-key", Value: "a-value"},
+		{Topic: "b", Value: "b-value"},
+		{Topic: "c", Key: "c-key"},
+	}, topics)
+	assert.Equal(t, []Subject{"com.mm.Event", "-value"}, others)
+}
+
+func TestTopics(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	keyID, valueID, err := RegisterTopic(tstClient(), ts.URL, "users", Schema(`"long"`), userV1)
+	require.NoError(t, err)
+	assert.NotEqual(t, keyID, valueID)
+
+	_, err = Register(tstClient(), ts.URL, ValueSubject("users"), userV2)
+	require.NoError(t, err)
+
+	_, err = SetSubjectCompatibility(tstClient(), ts.URL, KeySubject("users"), None)
+	require.NoError(t, err)
+
+	listings := 0
+	registry := ts.Config.Handler
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/subjects" {
+			listings++
+		}
+		registry.ServeHTTP(w, r)
+	})
+
+	info, err := DescribeTopic(tstClient(), ts.URL, "users")
+	require.NoError(t, err)
+	assert.Equal(t, TopicInfo{
+		Topic: "users",
+		Key:   &SubjectInfo{Subject: "users-key", Versions: []int{1}, ID: keyID, Schema: `"long"`, Compatibility: None},
+		Value: &SubjectInfo{Subject: "users-value", Versions: []int{1, 2}, ID: 3, Schema: userV2, Compatibility: Backward},
+	}, info)
+
+	_, valueOnly, err := RegisterTopic(tstClient(), ts.URL, "events", EmptySchema, userV1)
+	require.NoError(t, err)
+	assert.Equal(t, valueID, valueOnly)
+
+	info, err = DescribeTopic(tstClient(), ts.URL, "events")
+	require.NoError(t, err)
+	assert.Nil(t, info.Key)
+	assert.Equal(t, Subject("events-value"), info.Value.Subject)
+
+	_, err = DescribeTopic(tstClient(), ts.URL, "nope")
+	assert.Error(t, err)
+	assert.Equal(t, 0, listings, "the subjects are asked for directly")
+
+	_, _, err = RegisterTopic(tstClient(), ts.URL, "", userV1, userV1)
+	assert.Error(t, err)
+}
+
+func TestDescribeTopicListsOnUnclearNotFound(t *testing.T) {
+	listings := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subjects":
+			listings++
+			fmt.Fprint(w, `["events-value"]`)
+		case "/subjects/events-value/versions":
+			fmt.Fprint(w, `[1]`)
+		case "/subjects/events-value/versions/latest":
+			fmt.Fprint(w, `{"subject":"events-value","version":1,"id":4,"schema":"\"long\""}`)
+		case "/config/events-value":
+			fmt.Fprint(w, `{"compatibilityLevel":"FULL"}`)
+		default:
+			//a proxy in front of the registry that does not say what was not found
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	info, err := DescribeTopic(tstClient(), ts.URL, "events")
+	require.NoError(t, err)
+	assert.Nil(t, info.Key)
+	assert.Equal(t, &SubjectInfo{Subject: "events-value", Versions: []int{1}, ID: 4, Schema: `"long"`, Compatibility: Full}, info.Value)
+	assert.Equal(t, 1, listings)
+}
@@ -0,0 +1,134 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+//TraceParentHeader is the W3C trace context header used to propagate spans to the schema registry
+const TraceParentHeader = "traceparent"
+
+//Attribute is a key value pair recorded on a span
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+//Tracer starts spans.  It is small enough to be bridged to OpenTelemetry or any other tracing library without this package importing it.
+type Tracer interface {
+	//Start begins a span that is a child of any span in ctx and returns a context holding the new span
+	Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span)
+}
+
+//Span is a single traced call
+type Span interface {
+	//SetAttributes records attributes learned after the span started
+	SetAttributes(attributes ...Attribute)
+	//TraceParent returns the W3C traceparent value identifying the span, or "" if it should not be propagated
+	TraceParent() string
+	//End finishes the span, err is the error the call failed with if any
+	End(err error)
+}
+
+//Span attribute keys set by WithTracing
+const (
+	AttributeOperation  = "sr.operation"
+	AttributeSubject    = "sr.subject"
+	AttributeVersion    = "sr.version"
+	AttributeSchemaID   = "sr.schema_id"
+	AttributeMethod     = "http.method"
+	AttributeURL        = "http.url"
+	AttributeStatusCode = "http.status_code"
+)
+
+//WithTracing starts a span around every call made through the client, named after the call's operation and carrying its
+//subject, version and schema id, which for calls like Register comes from the response.  The span is a child of the span
+//in the request's context, see WithContext, and is propagated to the registry in the traceparent header.
+func WithTracing(tracer Tracer) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(request *http.Request) (*http.Response, error) {
+			call := CallOf(request)
+
+			attributes := []Attribute{
+				{Key: AttributeOperation, Value: string(call.Operation)},
+				{Key: AttributeMethod, Value: request.Method},
+				{Key: AttributeURL, Value: request.URL.Redacted()},
+			}
+			if call.Subject != EmptySubject {
+				attributes = append(attributes, Attribute{Key: AttributeSubject, Value: string(call.Subject)})
+			}
+			if call.Version != "" {
+				attributes = append(attributes, Attribute{Key: AttributeVersion, Value: call.Version})
+			}
+			if call.ID != 0 {
+				attributes = append(attributes, Attribute{Key: AttributeSchemaID, Value: int64(call.ID)})
+			}
+
+			ctx, span := tracer.Start(request.Context(), "sr."+string(call.Operation), attributes...)
+
+			request = request.Clone(ctx)
+			if traceParent := span.TraceParent(); traceParent != "" {
+				request.Header.Set(TraceParentHeader, traceParent)
+			}
+
+			response, err := next.Do(request)
+			if err == nil {
+				span.SetAttributes(Attribute{Key: AttributeStatusCode, Value: response.StatusCode})
+				if id := peekSchemaID(call, response); id != 0 {
+					span.SetAttributes(Attribute{Key: AttributeSchemaID, Value: id})
+				}
+			}
+
+			span.End(err)
+			return response, err
+		})
+	}
+}
+
+//peekSchemaID reads the id from the response of calls that return the id of a schema and leaves the body readable
+func peekSchemaID(call Call, response *http.Response) int64 {
+	if call.ID != 0 || response.StatusCode != http.StatusOK || response.Body == nil {
+		return 0
+	}
+
+	switch call.Operation {
+	case OpRegister, OpHasSchema, OpGetVersion:
+	default:
+		return 0
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 0
+	}
+
+	idResponse := struct {
+		ID uint32 `json:"id"`
+	}{}
+
+	if json.Unmarshal(body, &idResponse) != nil {
+		return 0
+	}
+
+	return int64(idResponse.ID)
+}
+
+//TraceParent formats a W3C traceparent header value
+func TraceParent(traceID [16]byte, spanID [8]byte, sampled bool) string {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+
+	return "00-" + hex.EncodeToString(traceID[:]) + "-" + hex.EncodeToString(spanID[:]) + "-" + flags
+}
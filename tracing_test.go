@@ -0,0 +1,128 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedSpan struct {
+	name       string
+	parent     *recordedSpan
+	attributes map[string]interface{}
+	ended      bool
+	err        error
+}
+
+func (s *recordedSpan) SetAttributes(attributes ...Attribute) {
+	for _, attribute := range attributes {
+		s.attributes[attribute.Key] = attribute.Value
+	}
+}
+
+func (s *recordedSpan) TraceParent() string {
+	return TraceParent([16]byte{0x4b, 0xf9, 0x2f}, [8]byte{0x00, 0xf0, 0x67}, true)
+}
+
+func (s *recordedSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+type spanKey struct{}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span) {
+	span := &recordedSpan{name: name, attributes: make(map[string]interface{})}
+	span.parent, _ = ctx.Value(spanKey{}).(*recordedSpan)
+	span.SetAttributes(attributes...)
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestWithTracing(t *testing.T) {
+	var traceParent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = r.Header.Get(TraceParentHeader)
+		fmt.Fprint(w, `{"subject":"goo","version":8,"id":19,"schema":"\"long\""}`)
+	}))
+	defer ts.Close()
+
+	tracer := &recordingTracer{}
+	_, _, err := GetVersion(Chain(tstClient(), WithTracing(tracer)), ts.URL, Subject("goo"), "8")
+	require.NoError(t, err)
+
+	require.Len(t, tracer.spans, 1)
+	span := tracer.spans[0]
+	assert.Equal(t, "sr.get_version", span.name)
+	assert.True(t, span.ended)
+	assert.NoError(t, span.err)
+	assert.Equal(t, "get_version", span.attributes[AttributeOperation])
+	assert.Equal(t, "goo", span.attributes[AttributeSubject])
+	assert.Equal(t, "8", span.attributes[AttributeVersion])
+	assert.Equal(t, 200, span.attributes[AttributeStatusCode])
+	assert.Equal(t, int64(19), span.attributes[AttributeSchemaID], "from the response")
+	assert.Nil(t, span.parent)
+	assert.Equal(t, "00-4bf92f00000000000000000000000000-00f0670000000000-01", traceParent)
+}
+
+func TestWithTracingSchemaIDAndFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	tracer := &recordingTracer{}
+	_, err := GetSchema(Chain(tstClient(), WithTracing(tracer)), url, 7878)
+	require.Error(t, err)
+
+	require.Len(t, tracer.spans, 1)
+	span := tracer.spans[0]
+	assert.Equal(t, "sr.get_schema", span.name)
+	assert.Equal(t, int64(7878), span.attributes[AttributeSchemaID])
+	assert.Error(t, span.err)
+	assert.NotContains(t, span.attributes, AttributeStatusCode)
+}
+
+func TestWithTracingParentAndResponseID(t *testing.T) {
+	ts := memoryRegistry()
+	defer ts.Close()
+
+	tracer := &recordingTracer{}
+	ctx, caller := tracer.Start(context.Background(), "caller")
+	client := Chain(tstClient(), WithTracing(tracer))
+
+	withPassword := strings.Replace(ts.URL, "http://", "http://ann:hunter3@", 1)
+	id, err := Register(WithContext(ctx)(client), withPassword, "users-value", userV1)
+	require.NoError(t, err)
+
+	_, found, err := HasSchema(Chain(tstClient(), WithContext(ctx), WithTracing(tracer)), ts.URL, "users-value", userV1)
+	require.NoError(t, err)
+
+	require.Len(t, tracer.spans, 3)
+	for _, span := range tracer.spans[1:] {
+		assert.Equal(t, caller.(*recordedSpan), span.parent, span.name)
+		assert.Equal(t, int64(id), span.attributes[AttributeSchemaID], span.name)
+	}
+	assert.Equal(t, int64(found), tracer.spans[2].attributes[AttributeSchemaID])
+
+	assert.NotContains(t, tracer.spans[1].attributes[AttributeURL], "hunter3")
+	assert.Contains(t, tracer.spans[1].attributes[AttributeURL], "ann:xxxxx@")
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Register(WithContext(canceled)(client), ts.URL, "users-value", userV2)
+	assert.Error(t, err)
+}
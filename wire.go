@@ -0,0 +1,113 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+//MagicByte is the first byte of every payload in the Confluent wire format
+const MagicByte byte = 0
+
+//HeaderSize is the length of the magic byte and schema id in front of every payload
+const HeaderSize = 5
+
+var (
+	//ErrInvalidMagicByte is wrapped by errors for payloads that do not start with MagicByte
+	ErrInvalidMagicByte = errors.New("invalid magic byte")
+	//ErrShortBuffer is wrapped by errors for payloads too short to hold their framing
+	ErrShortBuffer = errors.New("short buffer")
+)
+
+//Frame returns payload prefixed with the magic byte and schema id
+func Frame(id uint32, payload []byte) []byte {
+	framed := make([]byte, HeaderSize, HeaderSize+len(payload))
+	framed[0] = MagicByte
+	binary.BigEndian.PutUint32(framed[1:], id)
+	return append(framed, payload...)
+}
+
+//Unframe returns the schema id of a framed payload and the bytes after the header, which share data's memory
+func Unframe(data []byte) (id uint32, payload []byte, err error) {
+	if len(data) < HeaderSize {
+		return 0, nil, fmt.Errorf("%w: %v bytes is shorter than the %v byte header", ErrShortBuffer, len(data), HeaderSize)
+	}
+
+	if data[0] != MagicByte {
+		return 0, nil, fmt.Errorf("%w: %v", ErrInvalidMagicByte, data[0])
+	}
+
+	return binary.BigEndian.Uint32(data[1:HeaderSize]), data[HeaderSize:], nil
+}
+
+//FrameProtobuf returns payload prefixed with the magic byte, schema id and message indexes.  The indexes are the path
+//to the message type in the .proto file: [0] is the first message, [1, 0] the first message nested in the second.
+//Empty indexes are written as [0].
+func FrameProtobuf(id uint32, indexes []int, payload []byte) []byte {
+	framed := Frame(id, nil)
+	framed = append(framed, encodeMessageIndexes(indexes)...)
+	return append(framed, payload...)
+}
+
+//UnframeProtobuf returns the schema id and message indexes of a framed protobuf payload and the bytes after them
+func UnframeProtobuf(data []byte) (id uint32, indexes []int, payload []byte, err error) {
+	id, payload, err = Unframe(data)
+	if err == nil {
+		indexes, payload, err = decodeMessageIndexes(payload)
+	}
+
+	return
+}
+
+//encodeMessageIndexes writes the count and each index as zig zag varints, [0] is abbreviated to a count of 0
+func encodeMessageIndexes(indexes []int) []byte {
+	if len(indexes) == 0 || (len(indexes) == 1 && indexes[0] == 0) {
+		return []byte{0}
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64*(len(indexes)+1))
+	n := binary.PutVarint(buf, int64(len(indexes)))
+	for _, index := range indexes {
+		n += binary.PutVarint(buf[n:], int64(index))
+	}
+
+	return buf[:n]
+}
+
+func decodeMessageIndexes(data []byte) ([]int, []byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, nil, fmt.Errorf("%w: message index count", ErrShortBuffer)
+	}
+	data = data[n:]
+
+	if count == 0 {
+		return []int{0}, data, nil
+	}
+
+	if count < 0 {
+		return nil, nil, fmt.Errorf("invalid message index count %v", count)
+	}
+
+	//every index takes at least a byte
+	if count > int64(len(data)) {
+		return nil, nil, fmt.Errorf("%w: %v message indexes in %v bytes", ErrShortBuffer, count, len(data))
+	}
+
+	indexes := make([]int, count)
+	for i := range indexes {
+		index, n := binary.Varint(data)
+		if n <= 0 {
+			return nil, nil, fmt.Errorf("%w: message index %v of %v", ErrShortBuffer, i, count)
+		}
+
+		indexes[i] = int(index)
+		data = data[n:]
+	}
+
+	return indexes, data, nil
+}
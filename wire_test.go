@@ -0,0 +1,68 @@
+package sr
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrame(t *testing.T) {
+	framed := Frame(0x01020304, []byte("avro"))
+	assert.Equal(t, []byte{0, 1, 2, 3, 4, 'a', 'v', 'r', 'o'}, framed)
+
+	id, payload, err := Unframe(framed)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x01020304), id)
+	assert.Equal(t, []byte("avro"), payload)
+
+	id, payload, err = Unframe(Frame(7, nil))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), id)
+	assert.Empty(t, payload)
+}
+
+func TestUnframeErrors(t *testing.T) {
+	_, _, err := Unframe([]byte{0, 1, 2})
+	assert.True(t, errors.Is(err, ErrShortBuffer), "%v", err)
+
+	_, _, err = Unframe(nil)
+	assert.True(t, errors.Is(err, ErrShortBuffer), "%v", err)
+
+	_, _, err = Unframe([]byte{1, 0, 0, 0, 1, 'x'})
+	assert.True(t, errors.Is(err, ErrInvalidMagicByte), "%v", err)
+	assert.EqualError(t, err, "invalid magic byte: 1")
+}
+
+func TestFrameProtobuf(t *testing.T) {
+	assert.Equal(t, []byte{0, 0, 0, 0, 9, 0, 'p'}, FrameProtobuf(9, []int{0}, []byte("p")))
+	assert.Equal(t, []byte{0, 0, 0, 0, 9, 0, 'p'}, FrameProtobuf(9, nil, []byte("p")))
+	assert.Equal(t, []byte{0, 0, 0, 0, 9, 4, 2, 0, 'p'}, FrameProtobuf(9, []int{1, 0}, []byte("p")))
+
+	for _, indexes := range [][]int{{0}, {1, 0}, {3}, {200, 1, 70}} {
+		id, decoded, payload, err := UnframeProtobuf(FrameProtobuf(9, indexes, []byte("proto")))
+		require.NoError(t, err)
+		assert.Equal(t, uint32(9), id)
+		assert.Equal(t, indexes, decoded)
+		assert.Equal(t, []byte("proto"), payload)
+	}
+}
+
+func TestUnframeProtobufErrors(t *testing.T) {
+	_, _, _, err := UnframeProtobuf([]byte{0, 0, 0, 0, 9})
+	assert.True(t, errors.Is(err, ErrShortBuffer), "%v", err)
+
+	_, _, _, err = UnframeProtobuf([]byte{0, 0, 0, 0, 9, 4, 2})
+	assert.True(t, errors.Is(err, ErrShortBuffer), "%v", err)
+
+	_, _, _, err = UnframeProtobuf([]byte{0, 0, 0, 0, 9, 3})
+	assert.Error(t, err)
+
+	_, _, _, err = UnframeProtobuf([]byte{2, 0, 0, 0, 9, 0})
+	assert.True(t, errors.Is(err, ErrInvalidMagicByte), "%v", err)
+}